@@ -3,12 +3,14 @@ package ast
 import (
 	"arcane/token"
 	"bytes"
+	"math/big"
 	"strings"
 )
 
 type Node interface {
 	TokenLiteral() token.TokenLiteral
-	String() string // print AST nodes for debugging
+	String() string   // print AST nodes for debugging
+	Span() token.Span // the source range the node was parsed from
 }
 
 type Statement interface {
@@ -32,6 +34,15 @@ func (p *Program) TokenLiteral() token.TokenLiteral {
 	}
 	return ""
 }
+func (p *Program) Span() token.Span {
+	if len(p.Statements) == 0 {
+		return token.Span{}
+	}
+	return token.Span{
+		Start: p.Statements[0].Span().Start,
+		End:   p.Statements[len(p.Statements)-1].Span().End,
+	}
+}
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -49,6 +60,7 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenLiteral() token.TokenLiteral {
 	return i.Token.Literal
 }
+func (i *Identifier) Span() token.Span { return i.Token.Span }
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -56,16 +68,28 @@ func (i *Identifier) String() string {
 type IntegralLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int // set instead of Value when the literal does not fit in an int64
 }
 
 func (il *IntegralLiteral) expressionNode() {}
 func (il *IntegralLiteral) TokenLiteral() token.TokenLiteral {
 	return il.Token.Literal
 }
+func (il *IntegralLiteral) Span() token.Span { return il.Token.Span }
 func (il *IntegralLiteral) String() string {
 	return string(il.Token.Literal)
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()                  {}
+func (fl *FloatLiteral) TokenLiteral() token.TokenLiteral { return fl.Token.Literal }
+func (fl *FloatLiteral) Span() token.Span                 { return fl.Token.Span }
+func (fl *FloatLiteral) String() string                   { return string(fl.Token.Literal) }
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -75,7 +99,20 @@ func (b *Boolean) expressionNode() {}
 func (b *Boolean) TokenLiteral() token.TokenLiteral {
 	return b.Token.Literal
 }
-func (b *Boolean) String() string { return string(b.TokenLiteral()) }
+func (b *Boolean) Span() token.Span { return b.Token.Span }
+func (b *Boolean) String() string   { return string(b.TokenLiteral()) }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string // decoded value, without the quotes
+}
+
+func (sl *StringLiteral) expressionNode()                  {}
+func (sl *StringLiteral) TokenLiteral() token.TokenLiteral { return sl.Token.Literal }
+func (sl *StringLiteral) Span() token.Span                 { return sl.Token.Span }
+func (sl *StringLiteral) String() string                   { return `"` + stringEscaper.Replace(sl.Value) + `"` }
+
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
 
 // :: Let Statement
 type LetStatement struct {
@@ -88,6 +125,15 @@ func (ls *LetStatement) statementNode() {}
 func (ls *LetStatement) TokenLiteral() token.TokenLiteral {
 	return ls.Token.Literal
 }
+func (ls *LetStatement) Span() token.Span {
+	if ls.Value != nil {
+		return spanBetween(ls.Token, ls.Value)
+	}
+	if ls.Name != nil {
+		return spanBetween(ls.Token, ls.Name)
+	}
+	return ls.Token.Span
+}
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(string(ls.TokenLiteral()) + " ")
@@ -110,6 +156,7 @@ func (rs *ReturnStatement) statementNode() {}
 func (rs *ReturnStatement) TokenLiteral() token.TokenLiteral {
 	return rs.Token.Literal
 }
+func (rs *ReturnStatement) Span() token.Span { return spanBetween(rs.Token, rs.ReturnValue) }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(string(rs.TokenLiteral()))
@@ -130,6 +177,12 @@ func (es *ExpressionStatement) statementNode() {}
 func (es *ExpressionStatement) TokenLiteral() token.TokenLiteral {
 	return es.Token.Literal
 }
+func (es *ExpressionStatement) Span() token.Span {
+	if es.Expression != nil {
+		return es.Expression.Span()
+	}
+	return es.Token.Span
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -145,6 +198,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()                  {}
 func (pe *PrefixExpression) TokenLiteral() token.TokenLiteral { return pe.Token.Literal }
+func (pe *PrefixExpression) Span() token.Span                 { return spanBetween(pe.Token, pe.Right) }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -165,6 +219,13 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()                  {}
 func (ie *InfixExpression) TokenLiteral() token.TokenLiteral { return ie.Token.Literal }
+func (ie *InfixExpression) Span() token.Span {
+	span := spanBetween(ie.Token, ie.Right)
+	if ie.Left != nil {
+		span.Start = ie.Left.Span().Start
+	}
+	return span
+}
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -177,6 +238,35 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// LogicalExpression is && or ||, unlike an InfixExpression the right side is only evaluated when needed.
+type LogicalExpression struct {
+	Token    token.Token // && or ||
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (le *LogicalExpression) expressionNode()                  {}
+func (le *LogicalExpression) TokenLiteral() token.TokenLiteral { return le.Token.Literal }
+func (le *LogicalExpression) Span() token.Span {
+	span := spanBetween(le.Token, le.Right)
+	if le.Left != nil {
+		span.Start = le.Left.Span().Start
+	}
+	return span
+}
+func (le *LogicalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(le.Left.String())
+	out.WriteString(" " + le.Operator + " ")
+	out.WriteString(le.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type IfExpression struct {
 	Token       token.Token // if
 	Condition   Expression
@@ -186,6 +276,15 @@ type IfExpression struct {
 
 func (ife *IfExpression) expressionNode()                  {}
 func (ife *IfExpression) TokenLiteral() token.TokenLiteral { return ife.Token.Literal }
+func (ife *IfExpression) Span() token.Span {
+	if ife.Alternative != nil {
+		return spanBetween(ife.Token, ife.Alternative)
+	}
+	if ife.Consequence != nil {
+		return spanBetween(ife.Token, ife.Consequence)
+	}
+	return spanBetween(ife.Token, ife.Condition)
+}
 func (ife *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -203,12 +302,16 @@ func (ife *IfExpression) String() string {
 
 // BlockStatement ::
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // {
 	Statements []Statement
+	EndToken   token.Token // }
 }
 
 func (bs *BlockStatement) statementNode()                   {}
 func (bs *BlockStatement) TokenLiteral() token.TokenLiteral { return bs.Token.Literal }
+func (bs *BlockStatement) Span() token.Span {
+	return token.Span{Start: bs.Token.Span.Start, End: bs.EndToken.Span.End}
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -227,6 +330,12 @@ type FunctionLiteral struct {
 
 func (fl *FunctionLiteral) expressionNode()                  {}
 func (fl *FunctionLiteral) TokenLiteral() token.TokenLiteral { return fl.Token.Literal }
+func (fl *FunctionLiteral) Span() token.Span {
+	if fl.Body != nil {
+		return spanBetween(fl.Token, fl.Body)
+	}
+	return fl.Token.Span
+}
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	var params []string
@@ -240,14 +349,99 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+type ArrayLiteral struct {
+	Token    token.Token // [
+	Elements []Expression
+	EndToken token.Token // ]
+}
+
+func (al *ArrayLiteral) expressionNode()                  {}
+func (al *ArrayLiteral) TokenLiteral() token.TokenLiteral { return al.Token.Literal }
+func (al *ArrayLiteral) Span() token.Span {
+	return token.Span{Start: al.Token.Span.Start, End: al.EndToken.Span.End}
+}
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+	var elements []string
+
+	for _, e := range al.Elements {
+		elements = append(elements, e.String())
+	}
+	out.WriteString("[" + strings.Join(elements, ", ") + "]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token    token.Token // [
+	Left     Expression  // the indexed expression
+	Index    Expression
+	EndToken token.Token // ]
+}
+
+func (ie *IndexExpression) expressionNode()                  {}
+func (ie *IndexExpression) TokenLiteral() token.TokenLiteral { return ie.Token.Literal }
+func (ie *IndexExpression) Span() token.Span {
+	span := token.Span{Start: ie.Token.Span.Start, End: ie.EndToken.Span.End}
+	if ie.Left != nil {
+		span.Start = ie.Left.Span().Start
+	}
+	return span
+}
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[" + ie.Index.String() + "])")
+
+	return out.String()
+}
+
+type HashLiteral struct {
+	Token    token.Token // {
+	Pairs    []HashPair  // in source order
+	EndToken token.Token // }
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (hl *HashLiteral) expressionNode()                  {}
+func (hl *HashLiteral) TokenLiteral() token.TokenLiteral { return hl.Token.Literal }
+func (hl *HashLiteral) Span() token.Span {
+	return token.Span{Start: hl.Token.Span.Start, End: hl.EndToken.Span.End}
+}
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	var pairs []string
+
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	out.WriteString("{" + strings.Join(pairs, ", ") + "}")
+
+	return out.String()
+}
+
 type CallExpression struct {
 	Token     token.Token // (
 	Function  Expression  // identifier or function literal
 	Arguments []Expression
+	EndToken  token.Token // )
 }
 
 func (ce *CallExpression) expressionNode()                  {}
 func (ce *CallExpression) TokenLiteral() token.TokenLiteral { return ce.Token.Literal }
+func (ce *CallExpression) Span() token.Span {
+	span := token.Span{Start: ce.Token.Span.Start, End: ce.EndToken.Span.End}
+	if ce.Function != nil {
+		span.Start = ce.Function.Span().Start
+	}
+	return span
+}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	var args []string
@@ -260,3 +454,11 @@ func (ce *CallExpression) String() string {
 
 	return out.String()
 }
+
+// span from the start of the token up to the end of the node, or just the token when the node is missing
+func spanBetween(start token.Token, end Node) token.Span {
+	if end == nil {
+		return start.Span
+	}
+	return token.Span{Start: start.Span.Start, End: end.Span().End}
+}
@@ -0,0 +1,141 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fprint writes the tree of the node, one node per line with its position and source form,
+// and its children indented below it:
+//
+//	Program 1:1 `let x = (1 + 2);`
+//	  Statements[0]: LetStatement 1:1 `let x = (1 + 2);`
+//	    Name: Identifier 1:5 `x`
+//	      Value: "x"
+//	    Value: InfixExpression 1:9 `(1 + 2)`
+//	      ...
+func Fprint(w io.Writer, node Node) error {
+	var out strings.Builder
+	printNode(&out, "", node, 0)
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func printNode(out *strings.Builder, label string, node Node, depth int) {
+	fmt.Fprintf(out, "%s%s%s %s `%s`\n", strings.Repeat("  ", depth), label, nodeName(node), node.Span().Start, node.String())
+
+	for _, f := range nodeFields(node) {
+		printValue(out, f.name, f.value, depth+1)
+	}
+}
+
+func printValue(out *strings.Builder, label string, value reflect.Value, depth int) {
+	switch {
+	case isNode(value):
+		printNode(out, label+": ", value.Interface().(Node), depth)
+	case value.Kind() == reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			printValue(out, fmt.Sprintf("%s[%d]", label, i), value.Index(i), depth)
+		}
+	case value.Kind() == reflect.Struct: // HashPair
+		fmt.Fprintf(out, "%s%s:\n", strings.Repeat("  ", depth), label)
+		for i := 0; i < value.NumField(); i++ {
+			printValue(out, value.Type().Field(i).Name, value.Field(i), depth+1)
+		}
+	default:
+		text := fmt.Sprintf("%#v", value.Interface())
+		if stringer, ok := value.Interface().(fmt.Stringer); ok { // *big.Int
+			text = stringer.String()
+		}
+		fmt.Fprintf(out, "%s%s: %s\n", strings.Repeat("  ", depth), label, text)
+	}
+}
+
+// FprintJSON writes the tree of the node as JSON. Every node is an object with its kind and span,
+// and its fields under their names in lower camel case:
+//
+//	{"node": "Identifier", "span": {...}, "value": "x"}
+func FprintJSON(w io.Writer, node Node) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonValue(reflect.ValueOf(node)))
+}
+
+func jsonValue(value reflect.Value) interface{} {
+	switch {
+	case isNode(value):
+		node := value.Interface().(Node)
+		object := map[string]interface{}{"node": nodeName(node), "span": node.Span()}
+		for _, f := range nodeFields(node) {
+			object[lowerFirst(f.name)] = jsonValue(f.value)
+		}
+		return object
+	case value.Kind() == reflect.Slice:
+		array := make([]interface{}, value.Len())
+		for i := range array {
+			array[i] = jsonValue(value.Index(i))
+		}
+		return array
+	case value.Kind() == reflect.Struct: // HashPair
+		object := map[string]interface{}{}
+		for i := 0; i < value.NumField(); i++ {
+			object[lowerFirst(value.Type().Field(i).Name)] = jsonValue(value.Field(i))
+		}
+		return object
+	default:
+		return value.Interface()
+	}
+}
+
+type nodeField struct {
+	name  string
+	value reflect.Value
+}
+
+// nodeFields returns the fields of the node struct, without its tokens (they are in the span) and its nil children.
+func nodeFields(node Node) []nodeField {
+	value := reflect.ValueOf(node).Elem()
+	var fields []nodeField
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name := value.Type().Field(i).Name
+		if name == "Token" || name == "EndToken" {
+			continue
+		}
+		if (field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface) && field.IsNil() {
+			continue
+		}
+		fields = append(fields, nodeField{name: name, value: field})
+	}
+	return fields
+}
+
+func isNode(value reflect.Value) bool {
+	if value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return false
+	}
+	_, ok := value.Interface().(Node)
+	return ok
+}
+
+// name of the node type without the package, ex. LetStatement
+func nodeName(node Node) string {
+	return reflect.TypeOf(node).Elem().Name()
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
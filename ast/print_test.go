@@ -0,0 +1,88 @@
+package ast
+
+import (
+	"arcane/token"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func testProgram() *Program {
+	at := func(column int) token.Span {
+		return token.Span{
+			Start: token.Position{Offset: column - 1, Line: 1, Column: column},
+			End:   token.Position{Offset: column, Line: 1, Column: column + 1},
+		}
+	}
+
+	// let x = -y;
+	return &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let", Span: at(1)},
+				Name:  &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x", Span: at(5)}, Value: "x"},
+				Value: &PrefixExpression{
+					Token:    token.Token{Type: token.MINUS, Literal: "-", Span: at(9)},
+					Operator: "-",
+					Right:    &Identifier{Token: token.Token{Type: token.IDENT, Literal: "y", Span: at(10)}, Value: "y"},
+				},
+			},
+		},
+	}
+}
+
+func TestFprint(t *testing.T) {
+	expected := "Program 1:1 `let x = (-y);`\n" +
+		"  Statements[0]: LetStatement 1:1 `let x = (-y);`\n" +
+		"    Name: Identifier 1:5 `x`\n" +
+		"      Value: \"x\"\n" +
+		"    Value: PrefixExpression 1:9 `(-y)`\n" +
+		"      Operator: \"-\"\n" +
+		"      Right: Identifier 1:10 `y`\n" +
+		"        Value: \"y\"\n"
+
+	var out bytes.Buffer
+	if err := Fprint(&out, testProgram()); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("Fprint() wrong. expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestFprintJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := FprintJSON(&out, testProgram()); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Node       string `json:"node"`
+		Statements []struct {
+			Node string `json:"node"`
+			Name struct {
+				Node  string     `json:"node"`
+				Span  token.Span `json:"span"`
+				Value string     `json:"value"`
+			} `json:"name"`
+			Value struct {
+				Node     string `json:"node"`
+				Operator string `json:"operator"`
+			} `json:"value"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("FprintJSON() is not valid JSON: %v\n%s", err, out.String())
+	}
+
+	if decoded.Node != "Program" || len(decoded.Statements) != 1 {
+		t.Fatalf("wrong program in\n%s", out.String())
+	}
+	let := decoded.Statements[0]
+	if let.Node != "LetStatement" || let.Name.Node != "Identifier" || let.Name.Value != "x" || let.Name.Span.Start.Column != 5 {
+		t.Errorf("wrong let statement in\n%s", out.String())
+	}
+	if let.Value.Node != "PrefixExpression" || let.Value.Operator != "-" {
+		t.Errorf("wrong let value in\n%s", out.String())
+	}
+}
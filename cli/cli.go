@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"arcane/diagnostic"
+	"arcane/evaluator"
+	"arcane/lexer"
+	"arcane/object"
+	"arcane/parser"
+	"arcane/repl"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// Exit codes of the arcane command.
+const (
+	ExitOK           = 0
+	ExitRuntimeError = 1 // the program evaluated to an error
+	ExitUsage        = 2 // bad arguments, or the source could not be read
+	ExitParseError   = 3 // the program has syntax errors
+)
+
+const usage = `Usage:
+	arcane                           start the REPL, or run the program piped on stdin
+	arcane run <file.arc>            run a program, - reads it from stdin
+	arcane <file.arc>                same as run, for scripts starting with #!/usr/bin/env arcane
+	arcane -e <expression>           evaluate an expression and print its value
+	arcane tokens <source>           print the tokens with their position
+	arcane ast [-json] <source>      print the syntax tree, indented or as JSON
+	arcane help                      print this help
+
+A <source> is a file, - for stdin, or -e <expression>.
+`
+
+// Run executes the arcane command with the arguments after the program name and returns its exit code.
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		if isTerminal(stdin) {
+			greet(stdout)
+			repl.Start(stdin, stdout)
+			return ExitOK
+		}
+		return runReader("", stdin, stdout, stderr)
+	}
+
+	switch args[0] {
+	case "run":
+		if len(args) < 2 {
+			return usageError(stderr, "run needs a file, or - for stdin")
+		}
+		return runFile(args[1], stdin, stdout, stderr)
+	case "-e":
+		if len(args) < 2 {
+			return usageError(stderr, "-e needs an expression")
+		}
+		return runSource("", args[1], stdout, stderr)
+	case "tokens":
+		return runTokens(args[1:], stdin, stdout, stderr)
+	case "ast":
+		return runAST(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		_, _ = io.WriteString(stdout, usage)
+		return ExitOK
+	}
+
+	if strings.HasPrefix(args[0], "-") && args[0] != "-" {
+		return usageError(stderr, fmt.Sprintf("unknown flag %s", args[0]))
+	}
+	return runFile(args[0], stdin, stdout, stderr)
+}
+
+func usageError(stderr io.Writer, message string) int {
+	fmt.Fprintf(stderr, "arcane: %s\n\n%s", message, usage)
+	return ExitUsage
+}
+
+func runFile(filename string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if filename == "-" {
+		return runReader("", stdin, stdout, stderr)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(stderr, "arcane: %v\n", err)
+		return ExitUsage
+	}
+	defer file.Close()
+
+	// the file is read again only if there are diagnostics to quote
+	source := func() string {
+		text, _ := os.ReadFile(filename)
+		return string(text)
+	}
+	return runProgram(lexer.InitReader(filename, file), source, stdout, stderr)
+}
+
+// runReader lexes the program as it is read, only its start is kept for the diagnostics, see quotedSource.
+func runReader(filename string, reader io.Reader, stdout io.Writer, stderr io.Writer) int {
+	quoted := &quotedSource{reader: reader}
+	return runProgram(lexer.InitReader(filename, quoted), quoted.String, stdout, stderr)
+}
+
+func runSource(filename string, source string, stdout io.Writer, stderr io.Writer) int {
+	return runProgram(lexer.InitFile(filename, source), func() string { return source }, stdout, stderr)
+}
+
+// runProgram evaluates the program and prints its value, unless it is null. source gives the text the
+// diagnostics quote, it is only called when there are some.
+func runProgram(l *lexer.Lexer, source func() string, stdout io.Writer, stderr io.Writer) int {
+	p := parser.Init(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		diagnostic.Render(stderr, source(), p.Errors())
+		return ExitParseError
+	}
+
+	evaluated := evaluator.Eval(program, object.NewEnvironment())
+	switch evaluated := evaluated.(type) {
+	case nil:
+	case *object.Error:
+		fmt.Fprintln(stderr, evaluated.Inspect())
+		return ExitRuntimeError
+	case *object.Null:
+	default:
+		fmt.Fprintln(stdout, evaluated.Inspect())
+	}
+	return ExitOK
+}
+
+// bytes of a piped program kept for the diagnostics to quote, the lines past it are only given by position
+const maxQuotedSource = 1 << 20
+
+// quotedSource keeps the start of what is read through it, a stream like stdin cannot be read again.
+type quotedSource struct {
+	reader    io.Reader
+	text      strings.Builder
+	truncated bool
+}
+
+func (q *quotedSource) Read(b []byte) (int, error) {
+	n, err := q.reader.Read(b)
+	keep := min(n, maxQuotedSource-q.text.Len())
+	if keep < n {
+		q.truncated = true
+	}
+	q.text.Write(b[:keep])
+	return n, err
+}
+
+// String returns the complete lines kept.
+func (q *quotedSource) String() string {
+	text := q.text.String()
+	if q.truncated {
+		text = text[:strings.LastIndex(text, "\n")+1]
+	}
+	return text
+}
+
+// isTerminal reports whether the reader or writer is a terminal rather than a file or a pipe.
+func isTerminal(f interface{}) bool {
+	file, ok := f.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func greet(out io.Writer) {
+	name := "there"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	fmt.Fprintf(out, `
+=========================================
+Hello %s!
+
+This is Arcane Programming Language!
+
+Feel free to type in commands
+=========================================
+
+`, name)
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.arc")
+	source := "#!/usr/bin/env arcane\nlet add = fn(x, y) { x + y };\nadd(40, 2)\n"
+	if err := os.WriteFile(script, []byte(source), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string // contained in stderr
+	}{
+		{[]string{"run", script}, "", ExitOK, "42\n", ""},
+		{[]string{script}, "", ExitOK, "42\n", ""},
+		{[]string{"-e", "1 + 2 * 3"}, "", ExitOK, "7\n", ""},
+		{[]string{"-e", "let x = 1;"}, "", ExitOK, "", ""},
+		{[]string{"-e", "if (false) { 1 }"}, "", ExitOK, "", ""},
+		{[]string{}, "let x = 5; x * x", ExitOK, "25\n", ""},
+		{[]string{"run", "-"}, "\"a\" + \"b\"", ExitOK, "ab\n", ""},
+		{[]string{"-e", "let = 1;"}, "", ExitParseError, "", "error[E0001]: Expected next token to be IDENT. got ="},
+		{[]string{}, "let x 5;", ExitParseError, "", "1 | let x 5;"},
+		{[]string{"run", "-"}, "1;\nlet = 1;", ExitParseError, "", "2 | let = 1;"},
+		{[]string{"-e", "1 + true"}, "", ExitRuntimeError, "", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{[]string{"run", filepath.Join(dir, "missing.arc")}, "", ExitUsage, "", "no such file or directory"},
+		{[]string{"run"}, "", ExitUsage, "", "run needs a file"},
+		{[]string{"-e"}, "", ExitUsage, "", "-e needs an expression"},
+		{[]string{"--verbose"}, "", ExitUsage, "", "unknown flag --verbose"},
+		{[]string{"help"}, "", ExitOK, usage, ""},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("%v: wrong exit code. expected %d, got %d (stderr %q)", tt.args, tt.expectedCode, code, stderr.String())
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%v: wrong stdout. expected %q, got %q", tt.args, tt.expectedStdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderr) || tt.expectedStderr == "" && stderr.Len() != 0 {
+			t.Errorf("%v: wrong stderr. expected %q, got %q", tt.args, tt.expectedStderr, stderr.String())
+		}
+	}
+}
+
+func TestRunParseErrorPosition(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "bad.arc")
+	if err := os.WriteFile(script, []byte("#!/usr/bin/env arcane\nlet x 5;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	Run([]string{script}, strings.NewReader(""), &stdout, &stderr)
+
+	for _, expected := range []string{"--> " + script + ":2:7", "2 | let x 5;"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("stderr does not point at the error. expected %q in\n%s", expected, stderr.String())
+		}
+	}
+}
+
+func TestQuotedSource(t *testing.T) {
+	line := "let x = 1;\n"
+	input := strings.Repeat(line, maxQuotedSource/len(line)+10)
+	quoted := &quotedSource{reader: strings.NewReader(input)}
+
+	read, err := io.ReadAll(quoted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(read) != input {
+		t.Fatalf("the input is not passed through unchanged, got %d bytes of %d", len(read), len(input))
+	}
+
+	kept := quoted.String()
+	if len(kept) > maxQuotedSource || len(kept) < maxQuotedSource-len(line) {
+		t.Errorf("wrong number of bytes kept. expected at most %d, got %d", maxQuotedSource, len(kept))
+	}
+	if !strings.HasSuffix(kept, line) {
+		t.Errorf("the last line kept is cut, ends with %q", kept[len(kept)-len(line):])
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"arcane/ast"
+	"arcane/diagnostic"
+	"arcane/lexer"
+	"arcane/parser"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The tokens and ast subcommands show what the lexer and the parser make of a program, for debugging the grammar.
+
+// openSource opens the program given as <file.arc>, - for stdin, or -e <expression>.
+func openSource(args []string, stdin io.Reader) (string, io.ReadCloser, error) {
+	switch {
+	case len(args) == 2 && args[0] == "-e":
+		return "", io.NopCloser(strings.NewReader(args[1])), nil
+	case len(args) == 1 && args[0] == "-":
+		return "", io.NopCloser(stdin), nil
+	case len(args) == 1 && !strings.HasPrefix(args[0], "-"):
+		file, err := os.Open(args[0])
+		return args[0], file, err
+	}
+	return "", nil, fmt.Errorf("expected a file, - for stdin, or -e <expression>")
+}
+
+// runTokens prints one token per line with its position, type and literal, as the lexer produces them.
+func runTokens(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	filename, source, err := openSource(args, stdin)
+	if err != nil {
+		return usageError(stderr, "tokens: "+err.Error())
+	}
+	defer source.Close()
+
+	l := lexer.InitReader(filename, source)
+	if err := lexer.Fprint(stdout, l); err != nil {
+		fmt.Fprintf(stderr, "arcane: %v\n", err)
+		return ExitUsage
+	}
+
+	for _, d := range l.Errors() {
+		fmt.Fprintln(stderr, d.Error())
+	}
+	if len(l.Errors()) != 0 {
+		return ExitParseError
+	}
+	return ExitOK
+}
+
+// runAST prints the syntax tree of the program, indented or as JSON with -json.
+func runAST(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	printTree := ast.Fprint
+	if len(args) > 0 && args[0] == "-json" {
+		printTree = ast.FprintJSON
+		args = args[1:]
+	}
+
+	filename, reader, err := openSource(args, stdin)
+	if err != nil {
+		return usageError(stderr, "ast: "+err.Error())
+	}
+	defer reader.Close()
+
+	// the whole source is kept, diagnostics quote its lines
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		fmt.Fprintf(stderr, "arcane: %v\n", err)
+		return ExitUsage
+	}
+
+	p := parser.Init(lexer.InitFile(filename, string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		diagnostic.Render(stderr, string(source), p.Errors())
+		return ExitParseError
+	}
+
+	if err := printTree(stdout, program); err != nil {
+		fmt.Fprintf(stderr, "arcane: %v\n", err)
+		return ExitUsage
+	}
+	return ExitOK
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunTokens(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"tokens", "-e", "let x = 1;\nx >= 2"}, strings.NewReader(""), &stdout, &stderr)
+
+	expected := `1:1      LET        "let"
+1:5      IDENT      "x"
+1:7      =          "="
+1:9      INT        "1"
+1:10     ;          ";"
+2:1      IDENT      "x"
+2:3      >=         ">="
+2:6      INT        "2"
+2:7      EOF        ""
+`
+	if code != ExitOK || stderr.Len() != 0 {
+		t.Fatalf("tokens failed with code %d: %s", code, stderr.String())
+	}
+	if stdout.String() != expected {
+		t.Errorf("wrong tokens. expected\n%s\ngot\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunTokensError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"tokens", "-"}, strings.NewReader("1 @"), &stdout, &stderr)
+
+	if code != ExitParseError {
+		t.Errorf("wrong exit code. expected %d, got %d", ExitParseError, code)
+	}
+	if !strings.Contains(stdout.String(), `ILLEGAL    "@"`) {
+		t.Errorf("the ILLEGAL token is not printed:\n%s", stdout.String())
+	}
+	if stderr.String() != "1:3: error[E0007]: illegal character \"@\"\n" {
+		t.Errorf("wrong stderr %q", stderr.String())
+	}
+}
+
+func TestRunAST(t *testing.T) {
+	tests := []struct {
+		args           []string
+		expectedCode   int
+		expectedStdout string // contained in stdout
+	}{
+		{[]string{"ast", "-e", "1 + 2"}, ExitOK, "    Expression: InfixExpression 1:1 `(1 + 2)`\n"},
+		{[]string{"ast", "-json", "-e", "1 + 2"}, ExitOK, `"node": "InfixExpression"`},
+		{[]string{"ast", "-e", "1 +"}, ExitParseError, ""},
+		{[]string{"ast"}, ExitUsage, ""},
+		{[]string{"ast", "-json"}, ExitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("%v: wrong exit code. expected %d, got %d (stderr %q)", tt.args, tt.expectedCode, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.expectedStdout) {
+			t.Errorf("%v: wrong stdout. expected %q in\n%s", tt.args, tt.expectedStdout, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package diagnostic
+
+import (
+	"arcane/token"
+	"fmt"
+)
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+	NOTE
+)
+
+var severities = map[Severity]string{
+	ERROR:   "error",
+	WARNING: "warning",
+	NOTE:    "note",
+}
+
+func (s Severity) String() string { return severities[s] }
+
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Code identifies the kind of a diagnostic, so tools can match on it instead of the message.
+type Code string
+
+const (
+	UNEXPECTED_TOKEN     Code = "E0001" // the parser expected another token
+	NO_PREFIX_PARSE_FN   Code = "E0002" // the token cannot start an expression
+	INVALID_INTEGER      Code = "E0003" // the integer literal could not be parsed
+	TOO_MANY_ERRORS      Code = "E0004" // the parser gave up reporting errors
+	UNTERMINATED_STRING  Code = "E0005" // the string literal has no closing quote
+	INVALID_ESCAPE       Code = "E0006" // unknown or malformed escape sequence in a string literal
+	ILLEGAL_CHARACTER    Code = "E0007" // the character does not start any token
+	UNTERMINATED_COMMENT Code = "E0008" // the block comment has no closing */
+	MALFORMED_NUMBER     Code = "E0009" // the number literal is not well formed
+	INVALID_FLOAT        Code = "E0010" // the float literal could not be parsed
+	INVALID_UTF8         Code = "E0011" // the source is not valid UTF-8
+	READ_ERROR           Code = "E0012" // the source could not be read
+)
+
+type Diagnostic struct {
+	Severity Severity          `json:"severity"`
+	Code     Code              `json:"code"`
+	Span     token.Span        `json:"span"`
+	Message  string            `json:"message"`
+	Expected []token.TokenType `json:"expected,omitempty"` // token types that would have been valid here
+	Actual   *token.Token      `json:"actual,omitempty"`   // the offending token, nil when not relevant
+	Notes    []string          `json:"notes,omitempty"`
+}
+
+func Errorf(code Code, span token.Span, format string, a ...interface{}) Diagnostic {
+	return Diagnostic{
+		Severity: ERROR,
+		Code:     code,
+		Span:     span,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+// Error formats the diagnostic on one line, ex. main.arc:2:3: error[E0001]: Expected next token to be ). got ;
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Span.Start, d.Severity, d.Code, d.Message)
+}
@@ -0,0 +1,119 @@
+package diagnostic
+
+import (
+	"arcane/token"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func testDiagnostic() Diagnostic {
+	actual := token.Token{
+		Type:    token.SEMICOLON,
+		Literal: ";",
+		Span: token.Span{
+			Start: token.Position{Filename: "main.arc", Offset: 17, Line: 2, Column: 3},
+			End:   token.Position{Filename: "main.arc", Offset: 18, Line: 2, Column: 4},
+		},
+	}
+	d := Errorf(UNEXPECTED_TOKEN, actual.Span, "Expected next token to be %s. got %s", token.RIGHT_PARENTHESIS, actual.Type)
+	d.Expected = []token.TokenType{token.RIGHT_PARENTHESIS}
+	d.Actual = &actual
+	d.Notes = []string{"the call starts on line 1"}
+	return d
+}
+
+func TestError(t *testing.T) {
+	expected := "main.arc:2:3: error[E0001]: Expected next token to be ). got ;"
+	if testDiagnostic().Error() != expected {
+		t.Errorf("Error() wrong. expected %q, got %q", expected, testDiagnostic().Error())
+	}
+}
+
+func TestRender(t *testing.T) {
+	source := "let x = add(1,\n\t2;\n"
+	expected := `error[E0001]: Expected next token to be ). got ;
+ --> main.arc:2:3
+  |
+2 | 	2;
+  | 	 ^
+  = note: the call starts on line 1
+`
+
+	var out bytes.Buffer
+	Render(&out, source, []Diagnostic{testDiagnostic()})
+
+	if out.String() != expected {
+		t.Errorf("Render() wrong. expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRenderMultiLineSpan(t *testing.T) {
+	d := Errorf(NO_PREFIX_PARSE_FN, token.Span{
+		Start: token.Position{Line: 1, Column: 5},
+		End:   token.Position{Line: 2, Column: 2},
+	}, "bad")
+
+	var out bytes.Buffer
+	Render(&out, "let abc\n}", []Diagnostic{d})
+
+	expected := "error[E0002]: bad\n --> 1:5\n  |\n1 | let abc\n  |     ^^^\n"
+	if out.String() != expected {
+		t.Errorf("Render() wrong. expected\n%q\ngot\n%q", expected, out.String())
+	}
+}
+
+func TestRenderUnicodeLine(t *testing.T) {
+	d := Errorf(ILLEGAL_CHARACTER, token.Span{
+		Start: token.Position{Line: 1, Column: 10},
+		End:   token.Position{Line: 1, Column: 12},
+	}, "bad")
+
+	var out bytes.Buffer
+	Render(&out, "let π = é@@ 1", []Diagnostic{d})
+
+	expected := "error[E0007]: bad\n --> 1:10\n  |\n1 | let π = é@@ 1\n  |          ^^\n"
+	if out.String() != expected {
+		t.Errorf("Render() wrong. expected\n%q\ngot\n%q", expected, out.String())
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := RenderJSON(&out, []Diagnostic{testDiagnostic()}); err != nil {
+		t.Fatalf("RenderJSON() returned error: %s", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("RenderJSON() is not valid JSON: %s", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(decoded))
+	}
+
+	d := decoded[0]
+	if d["severity"] != "error" || d["code"] != "E0001" {
+		t.Errorf("wrong severity or code. got %v, %v", d["severity"], d["code"])
+	}
+	if expected := d["expected"].([]interface{}); len(expected) != 1 || expected[0] != ")" {
+		t.Errorf("wrong expected tokens. got %v", d["expected"])
+	}
+	if d["actual"].(map[string]interface{})["type"] != ";" {
+		t.Errorf("wrong actual token. got %v", d["actual"])
+	}
+	start := d["span"].(map[string]interface{})["start"].(map[string]interface{})
+	if start["filename"] != "main.arc" || start["line"] != float64(2) || start["column"] != float64(3) {
+		t.Errorf("wrong span start. got %v", start)
+	}
+}
+
+func TestRenderJSONEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := RenderJSON(&out, nil); err != nil {
+		t.Fatalf("RenderJSON() returned error: %s", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("expected an empty array, got %q", out.String())
+	}
+}
@@ -0,0 +1,78 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Render prints every diagnostic with the offending source line and a caret underline:
+//
+//	error[E0001]: Expected next token to be ). got ;
+//	 --> main.arc:2:3
+//	  |
+//	2 |  2;
+//	  |   ^
+//	  = note: ...
+func Render(w io.Writer, source string, diagnostics []Diagnostic) {
+	lines := strings.Split(source, "\n")
+
+	for _, d := range diagnostics {
+		start, end := d.Span.Start, d.Span.End
+		gutter := strings.Repeat(" ", len(strconv.Itoa(start.Line)))
+
+		fmt.Fprintf(w, "%s[%s]: %s\n", d.Severity, d.Code, d.Message)
+		fmt.Fprintf(w, "%s--> %s\n", gutter, start)
+
+		if start.Line >= 1 && start.Line <= len(lines) {
+			line := strings.TrimRight(lines[start.Line-1], "\r")
+
+			fmt.Fprintf(w, "%s |\n", gutter)
+			fmt.Fprintf(w, "%d | %s\n", start.Line, line)
+			fmt.Fprintf(w, "%s | %s\n", gutter, underline(line, start.Column, end.Column, end.Line == start.Line))
+		}
+
+		for _, note := range d.Notes {
+			fmt.Fprintf(w, "%s = note: %s\n", gutter, note)
+		}
+	}
+}
+
+// carets from the start column up to the end column, or to the end of the line when the span goes past it.
+// Columns count runes, not bytes.
+func underline(line string, startColumn int, endColumn int, sameLine bool) string {
+	chars := []rune(line)
+	if startColumn < 1 {
+		startColumn = 1
+	}
+	if !sameLine {
+		endColumn = len(chars) + 1
+	}
+	width := endColumn - startColumn
+	if width < 1 {
+		width = 1
+	}
+
+	// keep tabs so the carets line up with the source line
+	var padding strings.Builder
+	for i := 0; i < startColumn-1; i++ {
+		if i < len(chars) && chars[i] == '\t' {
+			padding.WriteByte('\t')
+		} else {
+			padding.WriteByte(' ')
+		}
+	}
+	return padding.String() + strings.Repeat("^", width)
+}
+
+// RenderJSON writes the diagnostics as a JSON array, for editors and CI.
+func RenderJSON(w io.Writer, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diagnostics)
+}
@@ -0,0 +1,161 @@
+package evaluator
+
+import (
+	"arcane/object"
+	"math"
+	"math/big"
+)
+
+// Integers are int64 until an operation overflows, the result is then a BigInteger.
+// A BigInteger result that fits in an int64 is demoted back to an Integer.
+//
+// Division truncates toward zero and the remainder has the sign of the dividend,
+// so a == (a / b) * b + a % b always holds.
+
+// results of ** are limited so a typo like 10 ** 10 ** 10 does not exhaust the memory
+const maxIntegerBits = 1 << 24
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftInteger, leftOk := left.(*object.Integer)
+	rightInteger, rightOk := right.(*object.Integer)
+	if !leftOk || !rightOk || operator == "**" {
+		return evalBigIntegerInfixExpression(operator, left, right)
+	}
+	leftValue, rightValue := leftInteger.Value, rightInteger.Value
+
+	switch operator {
+	case "+":
+		result := leftValue + rightValue
+		if (leftValue >= 0) == (rightValue >= 0) && (result >= 0) != (leftValue >= 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "-":
+		result := leftValue - rightValue
+		if (leftValue >= 0) != (rightValue >= 0) && (result >= 0) != (leftValue >= 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "*":
+		result := leftValue * rightValue
+		if leftValue != 0 && (result/leftValue != rightValue || leftValue == -1 && rightValue == math.MinInt64) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "/":
+		if rightValue == 0 {
+			return newError("division by zero: %d / %d", leftValue, rightValue)
+		}
+		if leftValue == math.MinInt64 && rightValue == -1 {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("division by zero: %d %% %d", leftValue, rightValue)
+		}
+		return &object.Integer{Value: leftValue % rightValue}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalBigIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftValue := toBigInt(left)
+	rightValue := toBigInt(right)
+
+	switch operator {
+	case "+":
+		return normalizeInteger(new(big.Int).Add(leftValue, rightValue))
+	case "-":
+		return normalizeInteger(new(big.Int).Sub(leftValue, rightValue))
+	case "*":
+		return normalizeInteger(new(big.Int).Mul(leftValue, rightValue))
+	case "/":
+		if rightValue.Sign() == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return normalizeInteger(new(big.Int).Quo(leftValue, rightValue))
+	case "%":
+		if rightValue.Sign() == 0 {
+			return newError("division by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return normalizeInteger(new(big.Int).Rem(leftValue, rightValue))
+	case "**":
+		return evalIntegerPower(left, right)
+	case "<":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue.Cmp(rightValue) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// a negative exponent gives a float, 2 ** -1 is 0.5, and 0 ** -1 is a division by zero
+func evalIntegerPower(left, right object.Object) object.Object {
+	base := toBigInt(left)
+	exponent := toBigInt(right)
+
+	if exponent.Sign() < 0 {
+		if base.Sign() == 0 {
+			return newError("division by zero: %s ** %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Pow(toFloat(left), toFloat(right))}
+	}
+
+	// 0, 1 and -1 stay small whatever the exponent
+	if base.CmpAbs(big.NewInt(1)) <= 0 {
+		if base.Sign() < 0 && exponent.Bit(0) == 1 {
+			return &object.Integer{Value: -1}
+		}
+		if base.Sign() == 0 && exponent.Sign() > 0 {
+			return &object.Integer{Value: 0}
+		}
+		return &object.Integer{Value: 1}
+	}
+
+	// the result has at least (bits of base - 1) * exponent bits, divided so a large exponent cannot overflow
+	if !exponent.IsInt64() || exponent.Int64() > maxIntegerBits/int64(base.BitLen()-1) {
+		return newError("integer too large: %s ** %s has more than %d bits", left.Inspect(), right.Inspect(), maxIntegerBits)
+	}
+	return normalizeInteger(new(big.Int).Exp(base, exponent, nil))
+}
+
+// normalizeInteger demotes the value to an Integer when it fits in an int64
+func normalizeInteger(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInteger{Value: value}
+}
+
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInteger:
+		return obj.Value
+	}
+	return new(big.Int)
+}
@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"arcane/object"
+	"testing"
+)
+
+func TestBigIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"-9223372036854775807 - 1 - 1", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"2 ** 64", "18446744073709551616"},
+		{"2 ** 100", "1267650600228229401496703205376"},
+		{"(-2) ** 63 / -1", "9223372036854775808"},
+		{"-((-2) ** 63)", "9223372036854775808"},
+		{"99999999999999999999999999", "99999999999999999999999999"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FF", "4722366482869645213695"},
+		{"100000000000000000000 % 7", "2"},
+		{"-100000000000000000000 / 3", "-33333333333333333333"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		if evaluated.Type() != object.INTEGER_OBJ {
+			t.Errorf("%q: object is not INTEGER. got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong value. expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"9223372036854775807 + 1 - 1", 9223372036854775807},
+		{"2 ** 64 / 2 ** 60", 16},
+		{"99999999999999999999 - 99999999999999999998", 1},
+		{"let big = 2 ** 70; big * 0", 0},
+		{"(-1) ** 100000000000000000000", 1},
+		{"(-1) ** 100000000000000000001", -1},
+		{"[1, 2, 3][2 ** 64 - 2 ** 64 + 1]", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Integer); !ok {
+			t.Errorf("%q: result was not demoted to Integer. got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBigIntegerComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"2 ** 64 == 18446744073709551616", true},
+		{"2 ** 64 != 2 ** 64 + 1", true},
+		{"2 ** 64 > 9223372036854775807", true},
+		{"-(2 ** 64) < -9223372036854775807", true},
+		{"2 ** 64 == 18446744073709551616.0", true},
+		{"{2 ** 64: true}[18446744073709551616]", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBigIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"2 ** 64 / 0", "division by zero: 18446744073709551616 / 0"},
+		{"[1][2 ** 64]", "index out of range: 18446744073709551616 (array length 1)"},
+		{"10 ** 100000000", "integer too large: 10 ** 100000000 has more than 16777216 bits"},
+		{"4 ** 4611686018427387904", "integer too large: 4 ** 4611686018427387904 has more than 16777216 bits"},
+		{"16 ** 3000000000000000000", "integer too large: 16 ** 3000000000000000000 has more than 16777216 bits"},
+		{"2 ** 16777217", "integer too large: 2 ** 16777217 has more than 16777216 bits"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got %T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected %q, got %q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package highlight
+
+import (
+	"arcane/lexer"
+	"arcane/token"
+	"html"
+	"io"
+	"os"
+	"strings"
+)
+
+// Class is the kind of source text a color is picked for.
+type Class int
+
+const (
+	PLAIN Class = iota // whitespace and delimiters
+	KEYWORD
+	IDENTIFIER
+	NUMBER
+	STRING
+	BOOLEAN
+	OPERATOR
+	COMMENT
+	ERROR // illegal tokens, error messages
+)
+
+var classNames = map[Class]string{
+	PLAIN:      "plain",
+	KEYWORD:    "keyword",
+	IDENTIFIER: "identifier",
+	NUMBER:     "number",
+	STRING:     "string",
+	BOOLEAN:    "boolean",
+	OPERATOR:   "operator",
+	COMMENT:    "comment",
+	ERROR:      "error",
+}
+
+func (c Class) String() string { return classNames[c] }
+
+// ANSI select graphic rendition codes of the classes
+var ansiCodes = map[Class]string{
+	KEYWORD:    "1;35",
+	IDENTIFIER: "36",
+	NUMBER:     "33",
+	STRING:     "32",
+	BOOLEAN:    "34",
+	OPERATOR:   "1",
+	COMMENT:    "90",
+	ERROR:      "1;31",
+}
+
+// Classify returns the class of a token type.
+func Classify(tokenType token.TokenType) Class {
+	switch tokenType {
+	case token.FUNCTION, token.LET, token.IF, token.ELSE, token.RETURN:
+		return KEYWORD
+	case token.TRUE, token.FALSE:
+		return BOOLEAN
+	case token.IDENT:
+		return IDENTIFIER
+	case token.INT, token.FLOAT:
+		return NUMBER
+	case token.STRING:
+		return STRING
+	case token.ILLEGAL:
+		return ERROR
+	case token.COMMA, token.SEMICOLON, token.COLON, token.DOT,
+		token.LEFT_PARENTHESIS, token.RIGHT_PARENTHESIS,
+		token.LEFT_CURLY_BRACKETS, token.RIGHT_CURLY_BRACKETS,
+		token.LEFT_SQUARE_BRACKETS, token.RIGHT_SQUARE_BRACKETS:
+		return PLAIN
+	}
+	if tokenType.IsOperator() {
+		return OPERATOR
+	}
+	return PLAIN
+}
+
+// Paint wraps the text in the ANSI color of the class.
+func Paint(class Class, text string) string {
+	code, ok := ansiCodes[class]
+	if !ok || text == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// ANSI colors the source for a terminal. The text is kept as is, whitespace and comments included.
+func ANSI(source string) string {
+	return highlight(source, Paint)
+}
+
+// HTML escapes the source and wraps every token in a span with the class arc-<class>, ex.
+//
+//	<span class="arc-keyword">let</span> <span class="arc-identifier">x</span>
+//
+// for a page to style and put in a <pre>.
+func HTML(source string) string {
+	return highlight(source, func(class Class, text string) string {
+		if class == PLAIN {
+			return html.EscapeString(text)
+		}
+		return `<span class="arc-` + class.String() + `">` + html.EscapeString(text) + "</span>"
+	})
+}
+
+// highlight splits the source into the text of its tokens and comments, and the plain text between them
+func highlight(source string, paint func(Class, string) string) string {
+	var out strings.Builder
+	offset := 0
+	write := func(class Class, span token.Span) {
+		out.WriteString(paint(PLAIN, source[offset:span.Start.Offset]))
+		out.WriteString(paint(class, source[span.Start.Offset:span.End.Offset]))
+		offset = span.End.Offset
+	}
+
+	l := lexer.Init(source)
+	l.KeepComments()
+	for {
+		tok := l.NextToken()
+		for _, comment := range tok.LeadingComments {
+			write(COMMENT, comment.Span)
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+		write(Classify(tok.Type), tok.Span)
+		for _, comment := range tok.TrailingComments {
+			write(COMMENT, comment.Span)
+		}
+	}
+
+	out.WriteString(paint(PLAIN, source[offset:]))
+	return out.String()
+}
+
+// Enabled reports whether colors should be written to w: it must be a terminal and NO_COLOR must not be set.
+func Enabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,115 @@
+package highlight
+
+import (
+	"arcane/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		tokenType token.TokenType
+		expected  Class
+	}{
+		{token.LET, KEYWORD},
+		{token.FUNCTION, KEYWORD},
+		{token.TRUE, BOOLEAN},
+		{token.IDENT, IDENTIFIER},
+		{token.INT, NUMBER},
+		{token.FLOAT, NUMBER},
+		{token.STRING, STRING},
+		{token.POWER, OPERATOR},
+		{token.AND, OPERATOR},
+		{token.ASSIGN, OPERATOR},
+		{token.SEMICOLON, PLAIN},
+		{token.LEFT_CURLY_BRACKETS, PLAIN},
+		{token.ILLEGAL, ERROR},
+		{token.EOF, PLAIN},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.tokenType); got != tt.expected {
+			t.Errorf("Classify(%s) wrong. expected %s, got %s", tt.tokenType, tt.expected, got)
+		}
+	}
+}
+
+func TestANSI(t *testing.T) {
+	input := "let x = 1.5 ** y; // note\nif (true) { \"hi\" } @"
+
+	expected := "\x1b[1;35mlet\x1b[0m \x1b[36mx\x1b[0m \x1b[1m=\x1b[0m \x1b[33m1.5\x1b[0m \x1b[1m**\x1b[0m \x1b[36my\x1b[0m; " +
+		"\x1b[90m// note\x1b[0m\n" +
+		"\x1b[1;35mif\x1b[0m (\x1b[34mtrue\x1b[0m) { \x1b[32m\"hi\"\x1b[0m } \x1b[1;31m@\x1b[0m"
+
+	if got := ANSI(input); got != expected {
+		t.Errorf("ANSI() wrong. expected\n%q\ngot\n%q", expected, got)
+	}
+}
+
+// the text is never changed, only wrapped
+func TestANSIKeepsSource(t *testing.T) {
+	inputs := []string{
+		"",
+		"   \n\t",
+		"#!/usr/bin/env arcane\nlet café = \"a\\tb\";",
+		"/* leading */ fn(a, b) { a + b } /* trailing */ // end",
+		"\"unterminated",
+		"/* unterminated",
+		"let s = \"\xff\";",
+	}
+
+	for _, input := range inputs {
+		stripped := ANSI(input)
+		for _, code := range ansiCodes {
+			stripped = strings.ReplaceAll(stripped, "\x1b["+code+"m", "")
+		}
+		stripped = strings.ReplaceAll(stripped, "\x1b[0m", "")
+
+		if stripped != input {
+			t.Errorf("ANSI() changed the source. expected %q, got %q", input, stripped)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	input := `let s = "<b>" && x;`
+
+	expected := `<span class="arc-keyword">let</span> <span class="arc-identifier">s</span> ` +
+		`<span class="arc-operator">=</span> <span class="arc-string">&#34;&lt;b&gt;&#34;</span> ` +
+		`<span class="arc-operator">&amp;&amp;</span> <span class="arc-identifier">x</span>;`
+
+	if got := HTML(input); got != expected {
+		t.Errorf("HTML() wrong. expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestPaint(t *testing.T) {
+	if got := Paint(ERROR, "boom"); got != "\x1b[1;31mboom\x1b[0m" {
+		t.Errorf("Paint() wrong, got %q", got)
+	}
+	if got := Paint(PLAIN, "x"); got != "x" {
+		t.Errorf("Paint() colored plain text, got %q", got)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	var buffer strings.Builder
+	if Enabled(&buffer) {
+		t.Errorf("colors enabled for a buffer")
+	}
+
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if Enabled(file) {
+		t.Errorf("colors enabled for a regular file")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if Enabled(os.Stdout) {
+		t.Errorf("colors enabled with NO_COLOR set")
+	}
+}
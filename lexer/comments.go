@@ -0,0 +1,122 @@
+package lexer
+
+import (
+	"arcane/diagnostic"
+	"arcane/token"
+	"unicode/utf8"
+)
+
+// Comments:
+//
+//	// runs to the end of the line
+//	/* runs to the matching closing marker, /* and can be nested */ */
+//
+// When the lexer keeps comments, a comment on the same line after a token is a trailing comment of that token,
+// every other comment is a leading comment of the token after it.
+
+// skipShebang skips a #! line at the very start of the input, so scripts can be run directly:
+//
+//	#!/usr/bin/env arcane
+func (l *Lexer) skipShebang() {
+	if l.position != 0 || l.ch != '#' || l.peekChar() != '!' {
+		return
+	}
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
+// skipTrivia skips the whitespace and comments before the next token, and returns the comments.
+// An unterminated block comment, or one that is not valid UTF-8, is reported and returned as an ILLEGAL token.
+func (l *Lexer) skipTrivia() ([]token.Comment, *token.Token) {
+	var comments []token.Comment
+
+	for {
+		l.skipWhiteSpace()
+
+		if !l.isCommentStart() {
+			return comments, nil
+		}
+
+		errors := len(l.errors)
+		comment, ok := l.readComment()
+		if !ok {
+			d := diagnostic.Errorf(diagnostic.UNTERMINATED_COMMENT, comment.Span, "unterminated block comment")
+			d.Notes = []string{"block comments nest, every /* needs its own */"}
+			l.addError(d)
+		}
+		if len(l.errors) > errors { // unterminated or invalid UTF-8, reported by readChar
+			tok := initToken(token.ILLEGAL, token.TokenLiteral(comment.Text))
+			tok.Span = comment.Span
+			return comments, &tok
+		}
+		comments = append(comments, comment)
+	}
+}
+
+// readTrailingComments reads the comments after a token up to the end of its line.
+// An unterminated or invalid block comment is left for skipTrivia to report.
+func (l *Lexer) readTrailingComments() []token.Comment {
+	var comments []token.Comment
+	line := l.line
+
+	for {
+		for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\v' || l.ch == '\f' {
+			l.readChar()
+		}
+
+		if l.line != line || !l.isCommentStart() {
+			return comments
+		}
+		if end, ok := l.commentEnd(); !ok || !utf8.ValidString(l.text(l.position, end)) {
+			return comments
+		}
+
+		comment, _ := l.readComment()
+		comments = append(comments, comment)
+	}
+}
+
+func (l *Lexer) isCommentStart() bool {
+	return l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*')
+}
+
+// readComment reads the comment starting at the current char, ok is false when a block comment is not closed.
+func (l *Lexer) readComment() (comment token.Comment, ok bool) {
+	start := l.currentPosition()
+
+	end, ok := l.commentEnd()
+	for l.position < end {
+		l.readChar()
+	}
+	return token.Comment{Text: l.text(start.Offset, end), Span: l.spanFrom(start)}, ok
+}
+
+// commentEnd returns the offset after the comment starting at the current char, ok is false when a block comment is not closed.
+func (l *Lexer) commentEnd() (end int, ok bool) {
+	line := l.peekChar() == '/'
+	depth := 0
+
+	for end = l.position; ; end++ {
+		ch, ok := l.byteAt(end)
+		if !ok {
+			return end, line
+		}
+		next, _ := l.byteAt(end + 1)
+
+		switch {
+		case line && ch == '\n':
+			return end, true
+		case line:
+		case ch == '/' && next == '*':
+			depth += 1
+			end++
+		case ch == '*' && next == '/':
+			depth -= 1
+			end++
+			if depth == 0 {
+				return end + 1, true
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package lexer
+
+import (
+	"arcane/token"
+	"testing"
+)
+
+func TestSkipComments(t *testing.T) {
+	input := `// leading line comment
+let x = 10; // trailing
+/* block /* nested */ still comment */ x / 2
+/**/x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.DIVIDE, "/"},
+		{token.INT, "2"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := Init(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokenType wrong. expected: %d, got: %d", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != token.TokenLiteral(tt.expectedLiteral) {
+			t.Fatalf("test[%d] - literal wrong. expected: %q, got: %q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.LeadingComments != nil || tok.TrailingComments != nil {
+			t.Fatalf("test[%d] - comments should not be kept by default", i)
+		}
+	}
+}
+
+func TestKeepComments(t *testing.T) {
+	input := `// doc for x
+// second line
+let x = 1; // one
+/* a */ y /* b */ /* c */
+/* multi
+line */
+z`
+
+	tests := []struct {
+		expectedLiteral  string
+		expectedLeading  []string
+		expectedTrailing []string
+	}{
+		{"let", []string{"// doc for x", "// second line"}, nil},
+		{"x", nil, nil},
+		{"=", nil, nil},
+		{"1", nil, nil},
+		{";", nil, []string{"// one"}},
+		{"y", []string{"/* a */"}, []string{"/* b */", "/* c */"}},
+		{"z", []string{"/* multi\nline */"}, nil},
+		{"", nil, nil},
+	}
+
+	l := Init(input)
+	l.KeepComments()
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != token.TokenLiteral(tt.expectedLiteral) {
+			t.Fatalf("test[%d] - literal wrong. expected: %q, got: %q", i, tt.expectedLiteral, tok.Literal)
+		}
+		testComments(t, i, "leading", tok.LeadingComments, tt.expectedLeading)
+		testComments(t, i, "trailing", tok.TrailingComments, tt.expectedTrailing)
+	}
+}
+
+func testComments(t *testing.T, i int, kind string, comments []token.Comment, expected []string) {
+	if len(comments) != len(expected) {
+		t.Fatalf("test[%d] - wrong number of %s comments. expected %d, got %d: %v", i, kind, len(expected), len(comments), comments)
+	}
+	for j, c := range comments {
+		if c.Text != expected[j] {
+			t.Fatalf("test[%d] - %s comment[%d] wrong. expected %q, got %q", i, kind, j, expected[j], c.Text)
+		}
+	}
+}
+
+func TestCommentSpan(t *testing.T) {
+	l := Init("x /* a\nb */ y")
+	l.KeepComments()
+
+	x := l.NextToken()
+	tok := l.NextToken()
+
+	expected := token.Span{
+		Start: token.Position{Offset: 2, Line: 1, Column: 3},
+		End:   token.Position{Offset: 11, Line: 2, Column: 5},
+	}
+	if len(x.TrailingComments) != 1 || x.TrailingComments[0].Span != expected {
+		t.Fatalf("wrong comment span. expected %+v, got %+v", expected, x.TrailingComments)
+	}
+	if tok.Span.Start.Line != 2 || tok.Span.Start.Column != 6 {
+		t.Fatalf("wrong token position after comment. got %s", tok.Span.Start)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		l := Init("x /* a /* b */ c")
+		if keep {
+			l.KeepComments()
+		}
+
+		if tok := l.NextToken(); tok.Type != token.IDENT {
+			t.Fatalf("expected IDENT, got %d", tok.Type)
+		}
+		tok := l.NextToken()
+		if tok.Type != token.ILLEGAL || tok.Literal != "/* a /* b */ c" {
+			t.Fatalf("expected ILLEGAL comment token, got %d %q", tok.Type, tok.Literal)
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("expected EOF, got %d", tok.Type)
+		}
+
+		expected := "1:3: error[E0008]: unterminated block comment"
+		if len(l.Errors()) != 1 || l.Errors()[0].Error() != expected {
+			t.Fatalf("expected error %q, got %v", expected, l.Errors())
+		}
+	}
+}
+
+func TestSkipShebang(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral token.TokenLiteral
+		expectedLine    int
+	}{
+		{"#!/usr/bin/env arcane\nlet", token.LET, "let", 2},
+		{"#!/usr/bin/env arcane", token.EOF, "", 1},
+		{" #!x", token.ILLEGAL, "#", 1}, // only at the very start
+	}
+
+	for i, tt := range tests {
+		tok := Init(tt.input).NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral || tok.Span.Start.Line != tt.expectedLine {
+			t.Errorf("test[%d] - expected %s %q on line %d, got %s %q on line %d", i,
+				tt.expectedType, tt.expectedLiteral, tt.expectedLine, tok.Type, tok.Literal, tok.Span.Start.Line)
+		}
+	}
+}
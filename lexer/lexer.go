@@ -1,97 +1,313 @@
 package lexer
 
-import "arcane/token"
+import (
+	"arcane/diagnostic"
+	"arcane/token"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Lexer struct {
-	input        string
+	filename     string
+	reader       io.Reader // the rest of the input, nil once it is all in buf
+	buf          []byte    // input from offset base on, see source.go
+	base         int
+	readErr      error
+	atEnd        bool // the current char is past the end of the input
 	position     int  // current position in input (points to current char)
 	readPosition int  //current read position in input (points to next char)
-	ch           byte //current char under examination
+	ch           rune //current char under examination, utf8.RuneError for an invalid byte
+	line         int  // line of the current char, starting at 1
+	column       int  // column of the current char in runes, starting at 1
+	errors       []diagnostic.Diagnostic
+	keepComments bool // attach comments to the tokens instead of dropping them
 }
 
 func Init(input string) *Lexer {
-	l := &Lexer{input: input}
+	return InitFile("", input)
+}
+
+// InitFile is like Init, but the positions of the tokens carry the filename.
+func InitFile(filename string, input string) *Lexer {
+	l := &Lexer{filename: filename, buf: []byte(input), line: 1}
+	l.readChar()
+	l.skipShebang()
+	return l
+}
+
+// InitReader lexes the input incrementally as the tokens are asked for, without reading it all first,
+// so large scripts and piped stdin can be processed in bounded memory.
+func InitReader(filename string, reader io.Reader) *Lexer {
+	l := &Lexer{filename: filename, reader: reader, line: 1}
 	l.readChar()
+	l.skipShebang()
 	return l
 }
 
+// Errors returns the diagnostics of the ILLEGAL tokens produced so far.
+func (l *Lexer) Errors() []diagnostic.Diagnostic {
+	return l.errors
+}
+
+func (l *Lexer) addError(d diagnostic.Diagnostic) {
+	l.errors = append(l.errors, d)
+}
+
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
+	if l.atEnd { // stay on EOF
+		return
+	}
+
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 1
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.column += 1
 	}
+
 	l.position = l.readPosition
-	l.readPosition += 1
+	ch, width := l.decodeAt(l.position)
+	if width == 0 {
+		l.ch = 0
+		l.readPosition += 1
+		l.atEnd = true
+		return
+	}
+
+	l.ch = ch
+	l.readPosition += width
+	if l.invalidEncoding() {
+		b, _ := l.byteAt(l.position)
+		l.addError(diagnostic.Errorf(diagnostic.INVALID_UTF8, l.charSpan(), "invalid UTF-8 encoding: byte %#02x", b))
+	}
+}
+
+// invalidEncoding reports whether the current char is a byte that is not valid UTF-8,
+// as opposed to a U+FFFD spelled out in the source.
+func (l *Lexer) invalidEncoding() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
 	}
-	return l.input[l.readPosition]
+}
+
+// span from start up to the current char
+func (l *Lexer) spanFrom(start token.Position) token.Span {
+	return token.Span{Start: start, End: l.currentPosition()}
+}
+
+// span of the current char only
+func (l *Lexer) charSpan() token.Span {
+	start := l.currentPosition()
+	end := start
+	end.Offset = l.readPosition
+	end.Column += 1
+	return token.Span{Start: start, End: end}
+}
+
+func (l *Lexer) peekChar() rune {
+	ch, _ := l.decodeAt(l.readPosition)
+	return ch
 }
 
 func (l *Lexer) readIdentifier() string {
 	positionOfFirstLetter := l.position
-	for isLetter(l.ch) {
+	for isIdentifierPart(l.ch) {
 		l.readChar()
 	}
-	return l.input[positionOfFirstLetter:l.position]
+	return l.text(positionOfFirstLetter, l.position)
 }
 
-func (l *Lexer) readNumber() string {
-	positionOfFirstNumber := l.position
-	for isDigit(l.ch) {
-		l.readChar()
+// KeepComments makes the lexer attach the comments to the adjacent tokens as leading and trailing comments,
+// for tools like a formatter. By default comments are skipped like whitespace.
+func (l *Lexer) KeepComments() {
+	l.keepComments = true
+}
+
+func (l *Lexer) NextToken() token.Token {
+	l.discard()
+	leading, illegal := l.skipTrivia()
+	if illegal != nil {
+		return *illegal
 	}
-	return l.input[positionOfFirstNumber:l.position]
+
+	tok := l.readToken()
+	if l.keepComments {
+		tok.LeadingComments = leading
+		tok.TrailingComments = l.readTrailingComments()
+	}
+	return tok
 }
 
 // Note: for letters and digits lexer position is advanced within their respective function
-func (l *Lexer) NextToken() token.Token {
-	l.skipWhiteSpace()
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
+	start := l.currentPosition()
 
 	switch {
 	case isLetter(l.ch):
 		tok.Literal = token.TokenLiteral(l.readIdentifier())
 		tok.Type = token.LookupIdentifier(string(tok.Literal))
+		tok.Span = l.spanFrom(start)
+		return tok
+	case isDigit(l.ch) || l.ch == '.' && isDigit(l.peekChar()):
+		tok = l.readNumber()
+		tok.Span = l.spanFrom(start)
+		return tok
+	case l.ch == '"':
+		tok = l.readString()
+		tok.Span = l.spanFrom(start)
 		return tok
-	case isDigit(l.ch):
-		tok.Literal = token.TokenLiteral(l.readNumber())
-		tok.Type = token.INT
+	case l.ch == 0 && l.readErr != nil:
+		tok = initToken(token.ILLEGAL, "")
+		tok.Span = l.spanFrom(start)
+		l.addError(diagnostic.Errorf(diagnostic.READ_ERROR, tok.Span, "cannot read the source: %v", l.readErr))
+		l.readErr = nil // reported once, EOF from now on
 		return tok
 	case l.ch == 0:
 		tok = initToken(token.EOF, "")
+	case l.invalidEncoding(): // already reported by readChar
+		tok = initToken(token.ILLEGAL, token.TokenLiteral(l.text(l.position, l.readPosition)))
+		l.readChar()
+		tok.Span = l.spanFrom(start)
+		return tok
 	default:
-		tok = initToken(token.ILLEGAL, token.TokenLiteral(l.ch))
-
-		for key, value := range token.Tokens {
-			if string(l.ch) == value {
-				switch value {
-				case "=": // ==
-					tok = l.makeTwoCharToken(key, token.ASSIGN, token.EQUAL)
-				case "!": // !=
-					tok = l.makeTwoCharToken(key, token.ASSIGN, token.NOT_EQUAL)
-				default:
-					tok = initToken(key, token.TokenLiteral(value))
-				}
-				break
-			}
+		if operator, ok := l.readOperator(); ok {
+			operator.Span = l.spanFrom(start)
+			return operator
 		}
+		tok = initToken(token.ILLEGAL, token.TokenLiteral(string(l.ch)))
 	}
 
 	l.readChar()
+	tok.Span = l.spanFrom(start)
+	if tok.Type == token.ILLEGAL {
+		l.addError(diagnostic.Errorf(diagnostic.ILLEGAL_CHARACTER, tok.Span, "illegal character %q", tok.Literal))
+	}
 	return tok
 }
 
+// readString reads a double-quoted string, the literal of the token is the decoded value.
+// A malformed string is reported and returned as an ILLEGAL token holding its source text.
+func (l *Lexer) readString() token.Token {
+	start := l.currentPosition()
+	var value strings.Builder
+	var invalidEscape *diagnostic.Diagnostic // only the first one of the string is reported
+	errors := len(l.errors)
+
+	l.readChar() // opening "
+	for l.ch != '"' {
+		if l.ch == 0 {
+			d := diagnostic.Errorf(diagnostic.UNTERMINATED_STRING, l.spanFrom(start), "unterminated string literal")
+			d.Notes = []string{"add a closing \" to end the string"}
+			l.addError(d)
+			return initToken(token.ILLEGAL, token.TokenLiteral(l.text(start.Offset, l.position)))
+		}
+
+		if l.ch != '\\' {
+			value.WriteRune(l.ch)
+			l.readChar()
+			continue
+		}
+
+		escapeStart := l.currentPosition()
+		decoded, ok := l.readEscape()
+		if !ok && invalidEscape == nil {
+			d := diagnostic.Errorf(diagnostic.INVALID_ESCAPE, l.spanFrom(escapeStart),
+				"invalid escape sequence %s", l.text(escapeStart.Offset, l.position))
+			d.Notes = []string{`valid escapes are \n, \t, \r, \", \\ and \u{...}`}
+			invalidEscape = &d
+		}
+		value.WriteString(decoded)
+	}
+	l.readChar() // closing "
+
+	if invalidEscape != nil {
+		l.addError(*invalidEscape)
+	}
+	if len(l.errors) > errors { // invalid escape or invalid UTF-8
+		return initToken(token.ILLEGAL, token.TokenLiteral(l.text(start.Offset, l.position)))
+	}
+	return initToken(token.STRING, token.TokenLiteral(value.String()))
+}
+
+// readEscape decodes the escape sequence starting at the current backslash, and leaves the lexer after it.
+func (l *Lexer) readEscape() (string, bool) {
+	l.readChar() // \
+	ch := l.ch
+
+	switch ch {
+	case 'n':
+		l.readChar()
+		return "\n", true
+	case 't':
+		l.readChar()
+		return "\t", true
+	case 'r':
+		l.readChar()
+		return "\r", true
+	case '"', '\\':
+		l.readChar()
+		return string(ch), true
+	case 'u':
+		return l.readUnicodeEscape()
+	case 0:
+		return "", false
+	default:
+		l.readChar()
+		return "", false
+	}
+}
+
+// \u{...} holds one to six hex digits of a unicode code point
+func (l *Lexer) readUnicodeEscape() (string, bool) {
+	l.readChar() // u
+	if l.ch != '{' {
+		return "", false
+	}
+	l.readChar()
+
+	positionOfFirstDigit := l.position
+	for isHexDigit(l.ch) {
+		l.readChar()
+	}
+	digits := l.text(positionOfFirstDigit, l.position)
+	if l.ch != '}' {
+		return "", false
+	}
+	l.readChar()
+
+	if len(digits) == 0 || len(digits) > 6 {
+		return "", false
+	}
+	var codePoint rune
+	for _, d := range digits {
+		codePoint = codePoint*16 + hexValue(d)
+	}
+	if !utf8.ValidRune(codePoint) {
+		return "", false
+	}
+	return string(codePoint), true
+}
+
 func (l *Lexer) skipWhiteSpace() {
-	for l.ch == ' ' || l.ch == '\n' || l.ch == '\t' || l.ch == '\r' || l.ch == '\v' || l.ch == '\f' {
+	for isWhiteSpace(l.ch) {
 		l.readChar()
 	}
 }
 
+func isWhiteSpace(ch rune) bool {
+	return ch == ' ' || ch == '\n' || ch == '\t' || ch == '\r' || ch == '\v' || ch == '\f'
+}
+
 func initToken(tokenType token.TokenType, literal token.TokenLiteral) token.Token {
 	return token.Token{
 		Type:    tokenType,
@@ -99,20 +315,39 @@ func initToken(tokenType token.TokenType, literal token.TokenLiteral) token.Toke
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// Identifiers follow UAX #31: they start with a letter (ID_Start) or _, and go on with letters, digits,
+// combining marks and connector punctuation (ID_Continue), so café and π are identifiers.
+
+func isLetter(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	}
+	return unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start)
+}
+
+func isIdentifierPart(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return isLetter(ch) || isDigit(ch)
+	}
+	return isLetter(ch) || unicode.In(ch, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
 }
 
-func isDigit(ch byte) bool {
+// only ASCII digits make numbers, other decimal digits are only allowed inside identifiers
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func (l *Lexer) makeTwoCharToken(firstChar token.TokenType, secondChar token.TokenType, doubleChar token.TokenType) token.Token {
-	ch := string(l.ch)
-	secondCh := token.Tokens[secondChar]
-	if string(l.peekChar()) == secondCh {
-		l.readChar()
-		return initToken(doubleChar, token.TokenLiteral(ch+secondCh))
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) rune {
+	switch {
+	case isDigit(ch):
+		return ch - '0'
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10
+	default:
+		return ch - 'A' + 10
 	}
-	return initToken(firstChar, token.TokenLiteral(ch))
 }
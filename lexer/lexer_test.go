@@ -14,7 +14,7 @@ let add = fn(x, y) {
 };
 let result = add(seventy, four);
 
-!-/*3;
+!-/ *3;
 
 5 < 10 > 5;
 
@@ -26,6 +26,9 @@ if (5 < 10) {
 
 6 == 6;
 15 != 9;
+[1, 2];
+a % b ** c <= d >= e;
+a && b || c;
 `
 
 	tests := []struct {
@@ -105,6 +108,28 @@ if (5 < 10) {
 		{token.NOT_EQUAL, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.LEFT_SQUARE_BRACKETS, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RIGHT_SQUARE_BRACKETS, "]"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.MODULES, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.IDENT, "c"},
+		{token.LT_EQUAL, "<="},
+		{token.IDENT, "d"},
+		{token.GT_EQUAL, ">="},
+		{token.IDENT, "e"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.SEMICOLON, ";"},
 	}
 
 	l := Init(input)
@@ -123,3 +148,230 @@ if (5 < 10) {
 	}
 
 }
+
+func TestNextTokenPosition(t *testing.T) {
+	input := "let x = 10;\n  x == 10\n"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedSpan token.Span
+	}{
+		{token.LET, token.Span{Start: token.Position{Filename: "main.arc", Offset: 0, Line: 1, Column: 1}, End: token.Position{Filename: "main.arc", Offset: 3, Line: 1, Column: 4}}},
+		{token.IDENT, token.Span{Start: token.Position{Filename: "main.arc", Offset: 4, Line: 1, Column: 5}, End: token.Position{Filename: "main.arc", Offset: 5, Line: 1, Column: 6}}},
+		{token.ASSIGN, token.Span{Start: token.Position{Filename: "main.arc", Offset: 6, Line: 1, Column: 7}, End: token.Position{Filename: "main.arc", Offset: 7, Line: 1, Column: 8}}},
+		{token.INT, token.Span{Start: token.Position{Filename: "main.arc", Offset: 8, Line: 1, Column: 9}, End: token.Position{Filename: "main.arc", Offset: 10, Line: 1, Column: 11}}},
+		{token.SEMICOLON, token.Span{Start: token.Position{Filename: "main.arc", Offset: 10, Line: 1, Column: 11}, End: token.Position{Filename: "main.arc", Offset: 11, Line: 1, Column: 12}}},
+		{token.IDENT, token.Span{Start: token.Position{Filename: "main.arc", Offset: 14, Line: 2, Column: 3}, End: token.Position{Filename: "main.arc", Offset: 15, Line: 2, Column: 4}}},
+		{token.EQUAL, token.Span{Start: token.Position{Filename: "main.arc", Offset: 16, Line: 2, Column: 5}, End: token.Position{Filename: "main.arc", Offset: 18, Line: 2, Column: 7}}},
+		{token.INT, token.Span{Start: token.Position{Filename: "main.arc", Offset: 19, Line: 2, Column: 8}, End: token.Position{Filename: "main.arc", Offset: 21, Line: 2, Column: 10}}},
+		{token.EOF, token.Span{Start: token.Position{Filename: "main.arc", Offset: 22, Line: 3, Column: 1}, End: token.Position{Filename: "main.arc", Offset: 22, Line: 3, Column: 1}}},
+		{token.EOF, token.Span{Start: token.Position{Filename: "main.arc", Offset: 22, Line: 3, Column: 1}, End: token.Position{Filename: "main.arc", Offset: 22, Line: 3, Column: 1}}},
+	}
+
+	l := InitFile("main.arc", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokenType wrong. expected: %d, got: %d", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Span != tt.expectedSpan {
+			t.Fatalf("test[%d] - span wrong. expected: %+v, got: %+v", i, tt.expectedSpan, tok.Span)
+		}
+	}
+}
+
+func TestNextTokenString(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"foobar"`, token.STRING, "foobar"},
+		{`"foo bar"`, token.STRING, "foo bar"},
+		{`""`, token.STRING, ""},
+		{`"a\nb\tc\r"`, token.STRING, "a\nb\tc\r"},
+		{`"say \"hi\" \\o/"`, token.STRING, `say "hi" \o/`},
+		{`"\u{41}\u{e9}\u{1F600}"`, token.STRING, "Aé😀"},
+		{"\"two\nlines\"", token.STRING, "two\nlines"},
+		{`"unterminated`, token.ILLEGAL, `"unterminated`},
+		{`"bad \q escape"`, token.ILLEGAL, `"bad \q escape"`},
+		{`"\u{110000}"`, token.ILLEGAL, `"\u{110000}"`},
+		{`"\u{}"`, token.ILLEGAL, `"\u{}"`},
+		{`"\u41"`, token.ILLEGAL, `"\u41"`},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokenType wrong. expected: %d, got: %d", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != token.TokenLiteral(tt.expectedLiteral) {
+			t.Fatalf("test[%d] - literal wrong. expected: %q, got: %q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Type == token.ILLEGAL && len(l.Errors()) != 1 {
+			t.Fatalf("test[%d] - expected 1 lexer error, got %d", i, len(l.Errors()))
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("test[%d] - expected EOF after the string, got %d", i, next.Type)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`let s = "abc`, "1:9: error[E0005]: unterminated string literal"},
+		{`"ab\qc"`, `1:4: error[E0006]: invalid escape sequence \q`},
+		{`"\u{d800}"`, `1:2: error[E0006]: invalid escape sequence \u{d800}`},
+		{`1 @ 2`, `1:3: error[E0007]: illegal character "@"`},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		if len(l.Errors()) != 1 {
+			t.Fatalf("test[%d] - expected 1 error, got %d", i, len(l.Errors()))
+		}
+		if l.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("test[%d] - wrong error. expected %q, got %q", i, tt.expectedError, l.Errors()[0].Error())
+		}
+	}
+}
+
+func TestNextTokenNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14"}}},
+		{".5", []token.Token{{Type: token.FLOAT, Literal: ".5"}}},
+		{"1e-9", []token.Token{{Type: token.FLOAT, Literal: "1e-9"}}},
+		{"2.5E+3", []token.Token{{Type: token.FLOAT, Literal: "2.5E+3"}}},
+		{"10e2", []token.Token{{Type: token.FLOAT, Literal: "10e2"}}},
+		{"42", []token.Token{{Type: token.INT, Literal: "42"}}},
+		{"1.foo", []token.Token{
+			{Type: token.INT, Literal: "1"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "foo"},
+		}},
+		{"1.5.foo", []token.Token{
+			{Type: token.FLOAT, Literal: "1.5"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "foo"},
+		}},
+		{"a.5", []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.FLOAT, Literal: ".5"},
+		}},
+		{"1e", []token.Token{{Type: token.ILLEGAL, Literal: "1e"}}},
+		{"1e+x", []token.Token{{Type: token.ILLEGAL, Literal: "1e+x"}}},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+
+		for j, expected := range append(tt.expected, token.Token{Type: token.EOF}) {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Fatalf("test[%d][%d] - wrong token. expected %d %q, got %d %q", i, j, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+	}
+}
+
+func TestMalformedNumberError(t *testing.T) {
+	l := Init("x = 2.5e;")
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := "1:5: error[E0009]: malformed number 2.5e"
+	if len(l.Errors()) != 1 || l.Errors()[0].Error() != expected {
+		t.Fatalf("expected error %q, got %v", expected, l.Errors())
+	}
+}
+
+func TestNextTokenUnicode(t *testing.T) {
+	input := "let café = \"naïve ☕\";\nπ * r_2 + x١ + é́"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral token.TokenLiteral
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1, 1},
+		{token.IDENT, "café", 1, 5},
+		{token.ASSIGN, "=", 1, 10},
+		{token.STRING, "naïve ☕", 1, 12},
+		{token.SEMICOLON, ";", 1, 21},
+		{token.IDENT, "π", 2, 1},
+		{token.MULTIPLY, "*", 2, 3},
+		{token.IDENT, "r_2", 2, 5},
+		{token.PLUS, "+", 2, 9},
+		{token.IDENT, "x١", 2, 11}, // Arabic-Indic digit one
+		{token.PLUS, "+", 2, 14},
+		{token.IDENT, "é́", 2, 16}, // combining acute accent
+		{token.EOF, "", 2, 18},
+	}
+
+	l := Init(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected %s %q, got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Span.Start.Line != tt.expectedLine || tok.Span.Start.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - wrong position for %q. expected %d:%d, got %d:%d", i, tok.Literal,
+				tt.expectedLine, tt.expectedColumn, tok.Span.Start.Line, tok.Span.Start.Column)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedType  token.TokenType
+		expectedError string
+	}{
+		{"let x = \xff;", token.ILLEGAL, "1:9: error[E0011]: invalid UTF-8 encoding: byte 0xff"},
+		{"\"é\xc3\"", token.ILLEGAL, "1:3: error[E0011]: invalid UTF-8 encoding: byte 0xc3"},
+		{"// ok \xfe\n1", token.ILLEGAL, "1:7: error[E0011]: invalid UTF-8 encoding: byte 0xfe"},
+		{"1 // \x80", token.INT, "1:6: error[E0011]: invalid UTF-8 encoding: byte 0x80"},
+		{"١", token.ILLEGAL, `1:1: error[E0007]: illegal character "١"`},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+		var types []token.TokenType
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			types = append(types, tok.Type)
+		}
+
+		found := false
+		for _, tokenType := range types {
+			found = found || tokenType == tt.expectedType
+		}
+		if !found {
+			t.Errorf("test[%d] - no %s token in %v", i, tt.expectedType, types)
+		}
+		if len(l.Errors()) != 1 {
+			t.Fatalf("test[%d] - expected 1 error, got %d", i, len(l.Errors()))
+		}
+		if l.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("test[%d] - wrong error. expected %q, got %q", i, tt.expectedError, l.Errors()[0].Error())
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package lexer
+
+import (
+	"arcane/diagnostic"
+	"arcane/token"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Number literals:
+//
+//	42  1_000_000  0xFF  0o755  0b1010    integers, underscores separate digits
+//	3.14  .5  1e-9  2_500.0               floats
+//
+// A dot is only part of the number when a digit follows it, so 1.foo is 1 . foo
+// Malformed numbers like 0x, 1__0, 1.5.5 or 0xFF.5 are reported and returned as ILLEGAL tokens.
+
+var integerBases = map[rune]struct {
+	name    string
+	isDigit func(rune) bool
+}{
+	'x': {"hexadecimal", isHexDigit},
+	'o': {"octal", func(ch rune) bool { return '0' <= ch && ch <= '7' }},
+	'b': {"binary", func(ch rune) bool { return ch == '0' || ch == '1' }},
+}
+
+// readNumber reads an integer or a float, the literal keeps the original spelling.
+func (l *Lexer) readNumber() token.Token {
+	start := l.currentPosition()
+
+	if l.ch == '0' && integerBases[toLower(l.peekChar())].name != "" {
+		l.readChar()
+		l.readChar()
+		for isIdentifierPart(l.ch) || l.isFraction() {
+			l.readChar()
+		}
+		return l.checkNumber(start, token.INT, validatePrefixedInteger)
+	}
+
+	tokenType := token.INT
+	l.readDigits()
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) {
+			for isIdentifierPart(l.ch) {
+				l.readChar()
+			}
+			literal := l.text(start.Offset, l.position)
+			d := diagnostic.Errorf(diagnostic.MALFORMED_NUMBER, l.spanFrom(start), "malformed number %s", literal)
+			d.Notes = []string{"an exponent needs at least one digit, ex. 1e9 or 1e-9"}
+			l.addError(d)
+			return initToken(token.ILLEGAL, token.TokenLiteral(literal))
+		}
+		l.readDigits()
+	}
+
+	// a second fraction is kept in the literal to be reported, instead of starting a float like .5
+	for tokenType == token.FLOAT && l.isFraction() {
+		l.readChar()
+		l.readDigits()
+	}
+
+	return l.checkNumber(start, tokenType, validateDecimal)
+}
+
+// a dot followed by a digit
+func (l *Lexer) isFraction() bool {
+	return l.ch == '.' && isDigit(l.peekChar())
+}
+
+// digits and the underscores between them
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// checkNumber validates the literal read since start. The validation returns the index of the offending
+// char in the literal and why it is wrong, or -1 when the literal is well formed.
+func (l *Lexer) checkNumber(start token.Position, tokenType token.TokenType, validate func(string) (int, string)) token.Token {
+	literal := l.text(start.Offset, l.position)
+
+	index, message := validate(literal)
+	if index == -1 {
+		return initToken(tokenType, token.TokenLiteral(literal))
+	}
+
+	at := start
+	at.Offset += index
+	at.Column += utf8.RuneCountInString(literal[:index])
+	end := at
+	_, width := utf8.DecodeRuneInString(literal[index:])
+	end.Offset += width
+	end.Column += 1
+	if index == len(literal) { // something is missing at the end, point at the whole literal
+		at, end = start, l.currentPosition()
+	}
+
+	l.addError(diagnostic.Errorf(diagnostic.MALFORMED_NUMBER, token.Span{Start: at, End: end}, "malformed number %s: %s", literal, message))
+	return initToken(token.ILLEGAL, token.TokenLiteral(literal))
+}
+
+func validatePrefixedInteger(literal string) (int, string) {
+	base := integerBases[toLower(rune(literal[1]))]
+	digits := 0
+
+	for i, ch := range literal {
+		if i < 2 { // 0x
+			continue
+		}
+		switch {
+		case ch == '_':
+			if i+1 == len(literal) || literal[i+1] == '_' {
+				return i, "'_' must separate successive digits"
+			}
+		case base.isDigit(ch):
+			digits += 1
+		case ch == '.':
+			return i, fmt.Sprintf("%s literal cannot have a fraction", base.name)
+		default:
+			return i, fmt.Sprintf("invalid digit %q in %s literal", ch, base.name)
+		}
+	}
+
+	if digits == 0 {
+		return len(literal), fmt.Sprintf("%s literal has no digits", base.name)
+	}
+	return -1, ""
+}
+
+func validateDecimal(literal string) (int, string) {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		if i+1 == len(literal) || !isDigit(rune(literal[i+1])) || !isDigit(rune(literal[i-1])) {
+			return i, "'_' must separate successive digits"
+		}
+	}
+
+	integerPart := literal
+	if end := strings.IndexAny(literal, ".eE"); end != -1 {
+		integerPart = literal[:end]
+		if dot := strings.IndexByte(literal[end+1:], '.'); dot != -1 {
+			return end + 1 + dot, "a number has one fraction at most, before its exponent"
+		}
+	}
+	// 0755 would silently be octal
+	if len(integerPart) > 1 && integerPart[0] == '0' {
+		return 0, "leading zeros are not allowed, use the 0o prefix for octal"
+	}
+	return -1, ""
+}
+
+func toLower(ch rune) rune {
+	if 'A' <= ch && ch <= 'Z' {
+		return ch + 'a' - 'A'
+	}
+	return ch
+}
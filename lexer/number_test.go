@@ -0,0 +1,83 @@
+package lexer
+
+import (
+	"arcane/token"
+	"testing"
+)
+
+func TestNextTokenIntegerForms(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType token.TokenType
+	}{
+		{"0xFF", token.INT},
+		{"0Xff", token.INT},
+		{"0o755", token.INT},
+		{"0b1010", token.INT},
+		{"0B1", token.INT},
+		{"1_000_000", token.INT},
+		{"0x_dead_BEEF", token.INT},
+		{"0", token.INT},
+		{"1_000.000_1", token.FLOAT},
+		{"1e1_0", token.FLOAT},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokenType wrong. expected: %d, got: %d", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != token.TokenLiteral(tt.input) {
+			t.Fatalf("test[%d] - literal wrong. expected: %q, got: %q", i, tt.input, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Fatalf("test[%d] - unexpected errors: %v", i, l.Errors())
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("test[%d] - expected EOF, got %d %q", i, next.Type, next.Literal)
+		}
+	}
+}
+
+func TestMalformedIntegers(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"0x", "1:1: error[E0009]: malformed number 0x: hexadecimal literal has no digits"},
+		{"0b", "1:1: error[E0009]: malformed number 0b: binary literal has no digits"},
+		{"0x_", "1:3: error[E0009]: malformed number 0x_: '_' must separate successive digits"},
+		{"0b102", "1:5: error[E0009]: malformed number 0b102: invalid digit '2' in binary literal"},
+		{"0o78", "1:4: error[E0009]: malformed number 0o78: invalid digit '8' in octal literal"},
+		{"0xFG", "1:4: error[E0009]: malformed number 0xFG: invalid digit 'G' in hexadecimal literal"},
+		{"1__0", "1:2: error[E0009]: malformed number 1__0: '_' must separate successive digits"},
+		{"x = 10_;", "1:7: error[E0009]: malformed number 10_: '_' must separate successive digits"},
+		{"1_.5", "1:2: error[E0009]: malformed number 1_.5: '_' must separate successive digits"},
+		{"0755", "1:1: error[E0009]: malformed number 0755: leading zeros are not allowed, use the 0o prefix for octal"},
+		{"1.5.5", "1:4: error[E0009]: malformed number 1.5.5: a number has one fraction at most, before its exponent"},
+		{"1e5.5", "1:4: error[E0009]: malformed number 1e5.5: a number has one fraction at most, before its exponent"},
+		{"0xFF.5", "1:5: error[E0009]: malformed number 0xFF.5: hexadecimal literal cannot have a fraction"},
+	}
+
+	for i, tt := range tests {
+		l := Init(tt.input)
+		var illegal *token.Token
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			if tok.Type == token.ILLEGAL && illegal == nil {
+				illegal = &tok
+			}
+		}
+
+		if illegal == nil {
+			t.Fatalf("test[%d] - expected an ILLEGAL token", i)
+		}
+		if len(l.Errors()) != 1 {
+			t.Fatalf("test[%d] - expected 1 error, got %d: %v", i, len(l.Errors()), l.Errors())
+		}
+		if l.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("test[%d] - wrong error. expected %q, got %q", i, tt.expectedError, l.Errors()[0].Error())
+		}
+	}
+}
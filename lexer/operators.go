@@ -0,0 +1,75 @@
+package lexer
+
+import (
+	"arcane/token"
+	"fmt"
+)
+
+// Operators and delimiters are scanned with a trie built from their spellings in token.Tokens, one level per
+// char. The lexer walks it as far as the input allows and keeps the longest operator on the way (maximal munch),
+// so ** is POWER and not MULTIPLY MULTIPLY, whatever the order of the map.
+
+type operatorNode struct {
+	children  [128]*operatorNode // operators are ASCII
+	tokenType token.TokenType
+	terminal  bool // an operator ends at this node
+}
+
+var operators = newOperatorTrie(token.Tokens)
+
+// newOperatorTrie panics when two operators share a spelling, the lexer could not tell them apart.
+func newOperatorTrie(spellings map[token.TokenType]string) *operatorNode {
+	root := &operatorNode{}
+
+	for tokenType, spelling := range spellings {
+		if !tokenType.IsOperator() {
+			continue
+		}
+
+		node := root
+		for i := 0; i < len(spelling); i++ {
+			ch := spelling[i]
+			if ch >= 128 {
+				panic(fmt.Sprintf("operator %q is not ASCII", spelling))
+			}
+			if node.children[ch] == nil {
+				node.children[ch] = &operatorNode{}
+			}
+			node = node.children[ch]
+		}
+
+		if node.terminal {
+			panic(fmt.Sprintf("token types %d and %d are both spelled %q", node.tokenType, tokenType, spelling))
+		}
+		node.terminal = true
+		node.tokenType = tokenType
+	}
+	return root
+}
+
+// readOperator reads the longest operator at the current char, ok is false when no operator starts there.
+func (l *Lexer) readOperator() (tok token.Token, ok bool) {
+	node := operators
+	length := 0
+
+	for i := 0; ; i++ {
+		ch, more := l.byteAt(l.position + i)
+		if !more || ch >= 128 || node.children[ch] == nil {
+			break
+		}
+		node = node.children[ch]
+		if node.terminal {
+			tok.Type = node.tokenType
+			length = i + 1
+		}
+	}
+
+	if length == 0 {
+		return tok, false
+	}
+	tok.Literal = token.TokenLiteral(l.text(l.position, l.position+length))
+	for i := 0; i < length; i++ {
+		l.readChar()
+	}
+	return tok, true
+}
@@ -0,0 +1,145 @@
+package lexer
+
+import (
+	"arcane/token"
+	"strings"
+	"testing"
+)
+
+func TestOperatorMaximalMunch(t *testing.T) {
+	input := "===!!=***<=>=&&&||| & |"
+
+	expected := []token.Token{
+		{Type: token.EQUAL, Literal: "=="},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.NOT, Literal: "!"},
+		{Type: token.NOT_EQUAL, Literal: "!="},
+		{Type: token.POWER, Literal: "**"},
+		{Type: token.MULTIPLY, Literal: "*"},
+		{Type: token.LT_EQUAL, Literal: "<="},
+		{Type: token.GT_EQUAL, Literal: ">="},
+		{Type: token.AND, Literal: "&&"},
+		{Type: token.ILLEGAL, Literal: "&"},
+		{Type: token.OR, Literal: "||"},
+		{Type: token.ILLEGAL, Literal: "|"},
+		{Type: token.ILLEGAL, Literal: "&"},
+		{Type: token.ILLEGAL, Literal: "|"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	testOperators(t, input, expected)
+}
+
+func TestThreeCharOperators(t *testing.T) {
+	defer func(trie *operatorNode) { operators = trie }(operators)
+	operators = newOperatorTrie(map[token.TokenType]string{
+		token.ASSIGN:    "=",
+		token.DOT:       ".",
+		token.COLON:     "..=",
+		token.SEMICOLON: "...",
+	})
+
+	input := "....=..=.."
+
+	expected := []token.Token{
+		{Type: token.SEMICOLON, Literal: "..."},
+		{Type: token.DOT, Literal: "."},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.COLON, Literal: "..="},
+		{Type: token.DOT, Literal: "."}, // .. is not an operator, back to the longest one on the way
+		{Type: token.DOT, Literal: "."},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	testOperators(t, input, expected)
+}
+
+func testOperators(t *testing.T, input string, expected []token.Token) {
+	t.Helper()
+
+	l := Init(input)
+	for i, want := range expected {
+		tok := l.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected %s %q, got %s %q", i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestOperatorTrieDuplicateSpelling(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for two operators with the same spelling")
+		}
+	}()
+
+	newOperatorTrie(map[token.TokenType]string{token.AND: "&&", token.OR: "&&"})
+}
+
+var benchmarkProgram = strings.Repeat(`let fib = fn(n) { if (n <= 1) { return n; } fib(n - 1) + fib(n - 2) };
+let x = [1, 2, 3][0] ** 2 % 3 != 4 && !(5 >= 6 || 7 == 8);
+let h = {"a": 1, "b": 2.5}; h["a"] * -h["b"] / 2;
+`, 2000)
+
+func BenchmarkNextToken(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkProgram)))
+	for i := 0; i < b.N; i++ {
+		l := Init(benchmarkProgram)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
+
+// the operators alone, with the trie and with the scan of token.Tokens it replaced
+func BenchmarkOperatorScan(b *testing.B) {
+	input := strings.Repeat("== != ** <= >= && || + - * / % ( ) { } [ ] , ; : . ! < > = ", 2000)
+
+	b.Run("trie", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			l := Init(input)
+			for l.ch != 0 {
+				if _, ok := l.readOperator(); !ok {
+					l.readChar()
+				}
+			}
+		}
+	})
+
+	b.Run("map", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < len(input); j++ {
+				j += mapScanOperator(input, j) - 1
+			}
+		}
+	})
+}
+
+// mapScanOperator is the old lookup: a scan of token.Tokens for the char, then a check of the next one.
+func mapScanOperator(input string, i int) int {
+	next := ""
+	if i+1 < len(input) {
+		next = input[i+1 : i+2]
+	}
+
+	for _, value := range token.Tokens {
+		if input[i:i+1] == value {
+			switch value {
+			case "=", "!", ">", "<":
+				if next == "=" {
+					return 2
+				}
+			case "*":
+				if next == "*" {
+					return 2
+				}
+			}
+			return 1
+		}
+	}
+	if (input[i] == '&' || input[i] == '|') && next == input[i:i+1] {
+		return 2
+	}
+	return 1
+}
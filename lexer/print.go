@@ -0,0 +1,24 @@
+package lexer
+
+import (
+	"arcane/token"
+	"fmt"
+	"io"
+)
+
+// Fprint writes the tokens left in the lexer up to EOF, one per line with its position, type and literal:
+//
+//	1:5      IDENT      "x"
+//	1:7      =          "="
+func Fprint(w io.Writer, l *Lexer) error {
+	for {
+		tok := l.NextToken()
+		position := fmt.Sprintf("%d:%d", tok.Span.Start.Line, tok.Span.Start.Column)
+		if _, err := fmt.Fprintf(w, "%-8s %-10s %q\n", position, tok.Type, tok.Literal); err != nil {
+			return err
+		}
+		if tok.Type == token.EOF {
+			return nil
+		}
+	}
+}
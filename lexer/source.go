@@ -0,0 +1,76 @@
+package lexer
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// The lexer reads its input through a sliding window: buf holds the input from offset base on, it is filled
+// chunk by chunk from the reader and the bytes before the current token are dropped once there are enough of them.
+// Offsets stay absolute, so positions do not depend on what is still buffered, and memory stays bounded by the
+// largest token or comment instead of the size of the program.
+
+const chunkSize = 4096
+
+// fill reads from the reader until the byte at offset is buffered, it returns false past the end of the input.
+func (l *Lexer) fill(offset int) bool {
+	for offset-l.base >= len(l.buf) {
+		if l.reader == nil {
+			return false
+		}
+		l.readChunk()
+	}
+	return true
+}
+
+func (l *Lexer) readChunk() {
+	if cap(l.buf)-len(l.buf) < chunkSize {
+		buf := make([]byte, len(l.buf), 2*cap(l.buf)+chunkSize)
+		copy(buf, l.buf)
+		l.buf = buf
+	}
+
+	n, err := l.reader.Read(l.buf[len(l.buf):cap(l.buf)])
+	l.buf = l.buf[:len(l.buf)+n]
+	if err != nil {
+		if err != io.EOF {
+			l.readErr = err
+		}
+		l.reader = nil
+	}
+}
+
+// discard drops the buffered bytes before the current char, they are not needed by the tokens to come.
+func (l *Lexer) discard() {
+	n := l.position - l.base
+	if l.reader == nil || n < chunkSize { // nothing more to read, or not worth a copy yet
+		return
+	}
+	l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+	l.base += n
+}
+
+func (l *Lexer) byteAt(offset int) (byte, bool) {
+	if !l.fill(offset) {
+		return 0, false
+	}
+	return l.buf[offset-l.base], true
+}
+
+// decodeAt decodes the rune at offset, the width is 0 past the end of the input.
+func (l *Lexer) decodeAt(offset int) (rune, int) {
+	l.fill(offset + utf8.UTFMax - 1)
+	if offset-l.base >= len(l.buf) {
+		return 0, 0
+	}
+	return utf8.DecodeRune(l.buf[offset-l.base:])
+}
+
+// text returns the source between the offsets start and end, start must still be buffered.
+func (l *Lexer) text(start int, end int) string {
+	l.fill(end - 1)
+	if end-l.base > len(l.buf) {
+		end = l.base + len(l.buf)
+	}
+	return string(l.buf[start-l.base : end-l.base])
+}
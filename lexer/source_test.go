@@ -0,0 +1,94 @@
+package lexer
+
+import (
+	"arcane/token"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestInitReader(t *testing.T) {
+	input := `let café = "naïve ☕"; /* nested /* block */ comment */
+let add = fn(x, y) { x + y }; // line comment
+add(0x1F, 2.5e3) >= 1_000 && !false
+`
+
+	expected := Init(input)
+	l := InitReader("main.arc", iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		want, got := expected.NextToken(), l.NextToken()
+
+		want.Span.Start.Filename, want.Span.End.Filename = "main.arc", "main.arc"
+		if got.Type != want.Type || got.Literal != want.Literal || got.Span != want.Span {
+			t.Fatalf("token[%d] - expected %s %q at %s, got %s %q at %s", i,
+				want.Type, want.Literal, want.Span, got.Type, got.Literal, got.Span)
+		}
+		if got.Type == token.EOF {
+			break
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+// the reader produces the source as it is read, the buffer must not grow with it
+func TestInitReaderBoundedBuffer(t *testing.T) {
+	const statements = 100_000
+	statement := "let x = 1_000 + y; // comment\n"
+
+	reader, writer := io.Pipe()
+	go func() {
+		for i := 0; i < statements; i++ {
+			_, _ = io.WriteString(writer, statement)
+		}
+		_ = writer.Close()
+	}()
+
+	l := InitReader("", reader)
+	count := 0
+	maxBuffered := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.LET {
+			count += 1
+		}
+		if cap(l.buf) > maxBuffered {
+			maxBuffered = cap(l.buf)
+		}
+	}
+
+	if count != statements {
+		t.Errorf("expected %d let statements, got %d", statements, count)
+	}
+	if maxBuffered > 4*chunkSize {
+		t.Errorf("buffer grew to %d bytes for a %d bytes input", maxBuffered, statements*len(statement))
+	}
+}
+
+func TestInitReaderError(t *testing.T) {
+	reader := io.MultiReader(strings.NewReader("let x = 1"), iotest.ErrReader(errors.New("disk on fire")))
+	l := InitReader("main.arc", reader)
+
+	var types []token.TokenType
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		types = append(types, tok.Type)
+	}
+
+	expectedTypes := []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.ILLEGAL}
+	if len(types) != len(expectedTypes) {
+		t.Fatalf("wrong tokens. expected %v, got %v", expectedTypes, types)
+	}
+	for i := range types {
+		if types[i] != expectedTypes[i] {
+			t.Fatalf("wrong tokens. expected %v, got %v", expectedTypes, types)
+		}
+	}
+
+	expectedError := "main.arc:1:10: error[E0012]: cannot read the source: disk on fire"
+	if len(l.Errors()) != 1 || l.Errors()[0].Error() != expectedError {
+		t.Errorf("wrong errors. expected %q, got %v", expectedError, l.Errors())
+	}
+}
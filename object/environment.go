@@ -0,0 +1,50 @@
+package object
+
+import "sort"
+
+// Environment holds the names bound by let statements and function parameters.
+// A function call gets a new environment enclosed by the one the function was defined in.
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get looks the name up in this environment, then in the enclosing ones.
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// Names returns the names bound in this environment and the enclosing ones, sorted.
+func (e *Environment) Names() []string {
+	seen := map[string]bool{}
+	var names []string
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			if !seen[name] { // shadowed by an inner environment
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Environment) Set(name string, value Object) Object {
+	e.store[name] = value
+	return value
+}
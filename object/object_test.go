@@ -0,0 +1,104 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+	diff2 := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+	if diff1.HashKey() != diff2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+}
+
+func TestHashKeyTypes(t *testing.T) {
+	one := &Integer{Value: 1}
+	yes := &Boolean{Value: true}
+
+	if one.HashKey() == yes.HashKey() {
+		t.Errorf("1 and true have the same hash key")
+	}
+	if yes.HashKey() != (&Boolean{Value: true}).HashKey() {
+		t.Errorf("booleans with same value have different hash keys")
+	}
+}
+
+func TestBigIntegerHashKey(t *testing.T) {
+	value, _ := new(big.Int).SetString("18446744073709551616", 10)
+	big1 := &BigInteger{Value: value}
+	big2 := &BigInteger{Value: new(big.Int).Set(value)}
+
+	if big1.HashKey() != big2.HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+	if big1.Type() != INTEGER_OBJ {
+		t.Errorf("big integer has wrong type. got %s", big1.Type())
+	}
+	if big1.Inspect() != "18446744073709551616" {
+		t.Errorf("Inspect() wrong. got %q", big1.Inspect())
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := NewHash()
+	hash.Set(&String{Value: "b"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 1}, &Boolean{Value: true})
+	hash.Set(&String{Value: "a"}, &Integer{Value: 2})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 3})
+
+	expected := "{b: 3, 1: true, a: 2}"
+	if hash.Inspect() != expected {
+		t.Errorf("Inspect() wrong. expected %q, got %q", expected, hash.Inspect())
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{3.0, "3.0"},
+		{3.14, "3.14"},
+		{-0.5, "-0.5"},
+		{1e21, "1e+21"},
+		{1e-9, "1e-09"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Inspect() wrong. expected %q, got %q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("b", &Integer{Value: 1})
+	outer.Set("a", &Integer{Value: 2})
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("c", &Integer{Value: 3})
+	inner.Set("a", &Integer{Value: 4})
+
+	names := inner.Names()
+	expected := []string{"a", "b", "c"}
+	if len(names) != len(expected) {
+		t.Fatalf("Names() wrong. expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("Names() wrong. expected %v, got %v", expected, names)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package parser
 
 import (
 	"arcane/ast"
+	"arcane/diagnostic"
 	"arcane/lexer"
 	"arcane/token"
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
@@ -13,9 +16,16 @@ type Parser struct {
 
 	currentToken   token.Token
 	peekToken      token.Token
-	errors         []string
+	errors         []diagnostic.Diagnostic
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	panicking  bool // an error was reported in the current statement, further errors are suppressed until synchronize
+	blockDepth int  // number of block statements currently being parsed
+	braceDepth int  // number of { not closed yet before the current token, see synchronize
+
+	tracer     func(TraceEvent) // see SetTrace, nil when not tracing
+	traceDepth int
 }
 
 type (
@@ -27,23 +37,33 @@ type (
 const (
 	_ int = iota
 	LOWEST
+	OR          // ||
+	AND         // &&
 	EQUALS      // ==
-	LESS_GRATER // > or <
+	LESS_GRATER // > or < or >= or <=
 	SUM         // +
-	PRODUCT     // *
+	PRODUCT     // * or / or %
 	PREFIX      // -x or !x
+	EXPONENT    // x ** y, binds tighter than a prefix so -x ** y is -(x ** y)
 	CALL        // myFunction(x)
+	INDEX       // array[index]
 )
 
+// errors reported before the parser stops
+const MAX_ERRORS = 10
+
 func Init(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []diagnostic.Diagnostic{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
@@ -51,17 +71,26 @@ func Init(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LEFT_PARENTHESIS, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.LEFT_SQUARE_BRACKETS, p.parseArrayLiteral)
+	p.registerPrefix(token.LEFT_CURLY_BRACKETS, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.DIVIDE, p.parseInfixExpression)
 	p.registerInfix(token.MULTIPLY, p.parseInfixExpression)
+	p.registerInfix(token.MODULES, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseLogicalExpression)
+	p.registerInfix(token.OR, p.parseLogicalExpression)
 	p.registerInfix(token.LEFT_PARENTHESIS, p.parseCallExpression)
+	p.registerInfix(token.LEFT_SQUARE_BRACKETS, p.parseIndexExpression)
 
 	// twice so current and peek tokens are set
 	p.nextToken()
@@ -73,18 +102,45 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.currentToken, Value: string(p.currentToken.Literal)}
 }
 func (p *Parser) parseIntegerLiteral() ast.Expression {
-	//defer unTrace(trace("parseIntegerLiteral"))
+	defer p.unTrace(p.trace("parseIntegerLiteral", 0))
 	literal := &ast.IntegralLiteral{Token: p.currentToken}
 
 	value, err := strconv.ParseInt(string(p.currentToken.Literal), 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if bigValue, ok := new(big.Int).SetString(string(p.currentToken.Literal), 0); ok {
+			literal.Big = bigValue
+			return literal
+		}
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(diagnostic.Errorf(diagnostic.INVALID_INTEGER, p.currentToken.Span,
+			"could not parse %q as integer", p.currentToken.Literal))
 		return nil
 	}
 	literal.Value = value
 	return literal
 }
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: p.currentToken}
+
+	value, err := strconv.ParseFloat(string(p.currentToken.Literal), 64)
+	if err != nil {
+		p.addError(diagnostic.Errorf(diagnostic.INVALID_FLOAT, p.currentToken.Span,
+			"could not parse %q as float", p.currentToken.Literal))
+		return nil
+	}
+	literal.Value = value
+	return literal
+}
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.currentToken, Value: string(p.currentToken.Literal)}
+}
+
+// the lexer already reported why the token is illegal
+func (p *Parser) parseIllegal() ast.Expression {
+	p.illegalTokenError(p.currentToken)
+	return nil
+}
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.currentToken, Value: p.currentTokenIs(token.TRUE)}
 }
@@ -100,7 +156,7 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
-	//defer unTrace(trace("parsePrefixExpression"))
+	defer p.unTrace(p.trace("parsePrefixExpression", 0))
 	expression := &ast.PrefixExpression{
 		Token:    p.currentToken,
 		Operator: string(p.currentToken.Literal),
@@ -114,29 +170,120 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 }
 
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	//defer unTrace(trace("parseInfixExpression"))
 	expression := &ast.InfixExpression{
 		Token:    p.currentToken,
 		Operator: string(p.currentToken.Literal),
 		Left:     left,
 	}
 	precedence := p.currentPrecedence()
+	defer p.unTrace(p.trace("parseInfixExpression", precedence))
+	if p.currentTokenIs(token.POWER) {
+		precedence -= 1 // right-associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	}
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	expression := &ast.LogicalExpression{
+		Token:    p.currentToken,
+		Operator: string(p.currentToken.Literal),
+		Left:     left,
+	}
+	precedence := p.currentPrecedence()
+	defer p.unTrace(p.trace("parseLogicalExpression", precedence))
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() []diagnostic.Diagnostic {
 	return p.errors
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("Expected next token to be %s. got %s", token.Tokens[t], token.Tokens[p.peekToken.Type])
-	p.errors = append(p.errors, msg)
+	if p.peekTokenIs(token.ILLEGAL) {
+		p.illegalTokenError(p.peekToken)
+		return
+	}
+	actual := p.peekToken
+	d := diagnostic.Errorf(diagnostic.UNEXPECTED_TOKEN, actual.Span,
+		"Expected next token to be %s. got %s", t, actual.Type)
+	d.Expected = []token.TokenType{t}
+	d.Actual = &actual
+	p.addError(d)
+}
+
+// addError reports the first error of a statement, the ones after it are most likely caused by the same mistake.
+// Past MAX_ERRORS, the first error dropped is replaced by a too many errors one and the parser stops.
+func (p *Parser) addError(d diagnostic.Diagnostic) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+
+	if len(p.errors) >= MAX_ERRORS {
+		if len(p.errors) == MAX_ERRORS {
+			p.errors = append(p.errors, diagnostic.Errorf(diagnostic.TOO_MANY_ERRORS, d.Span,
+				"too many errors, stopped after %d", MAX_ERRORS))
+		}
+		return
+	}
+	p.errors = append(p.errors, d)
+}
+
+// synchronize skips the rest of a broken statement and leaves the current token at the start of the next one:
+// after a semicolon, on a let/return/fn keyword, or on the closing bracket of the enclosing block.
+// start is the brace depth where the statement started, so a } closing a hash or a block opened in the
+// statement is skipped with it instead of being taken for the end of the enclosing block.
+func (p *Parser) synchronize(start int) {
+	p.panicking = false
+
+	for !p.currentTokenIs(token.EOF) {
+		switch p.currentToken.Type {
+		case token.SEMICOLON:
+			if p.braceDepth == start {
+				p.nextToken()
+				return
+			}
+		case token.RIGHT_CURLY_BRACKETS:
+			if p.braceDepth <= start {
+				// closes the enclosing block, or a stray one that is skipped at the top level
+				if p.blockDepth == 0 {
+					p.nextToken()
+				}
+				return
+			}
+		}
+
+		p.nextToken()
+
+		if p.braceDepth == start && isStatementBoundary(p.currentToken.Type) {
+			return
+		}
+	}
+}
+
+func isStatementBoundary(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.RETURN, token.FUNCTION:
+		return true
+	}
+	return false
 }
 
 func (p *Parser) nextToken() {
+	switch p.currentToken.Type {
+	case token.LEFT_CURLY_BRACKETS:
+		p.braceDepth += 1
+	case token.RIGHT_CURLY_BRACKETS:
+		if p.braceDepth > 0 {
+			p.braceDepth -= 1
+		}
+	}
 	p.currentToken = p.peekToken
 	p.peekToken = p.l.NextToken()
 }
@@ -146,8 +293,13 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
-	for !p.currentTokenIs(token.EOF) {
+	for !p.currentTokenIs(token.EOF) && len(p.errors) <= MAX_ERRORS {
+		start := p.braceDepth
 		stmt := p.parseStatement()
+		if p.panicking {
+			p.synchronize(start)
+			continue
+		}
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -189,9 +341,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
-	}
+	p.skipSemicolon()
 
 	return stmt
 }
@@ -204,9 +354,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	p.nextToken()
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
-	}
+	p.skipSemicolon()
 
 	return stmt
 }
@@ -250,17 +398,63 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 	block.Statements = []ast.Statement{}
 
+	p.blockDepth += 1
+	defer func() { p.blockDepth -= 1 }()
+
 	p.nextToken()
 	for !p.currentTokenIs(token.RIGHT_CURLY_BRACKETS) && !p.currentTokenIs(token.EOF) {
+		start := p.braceDepth
 		stmt := p.parseStatement()
+		if p.panicking {
+			p.synchronize(start)
+			continue
+		}
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	block.EndToken = p.currentToken
 	return block
 }
 
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.currentToken}
+
+	if p.peekTokenIs(token.RIGHT_CURLY_BRACKETS) {
+		p.nextToken()
+		hash.EndToken = p.currentToken
+		return hash
+	}
+	p.nextToken()
+	key := p.parseExpression(LOWEST)
+
+	for {
+		if !p.expectedPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		if p.peekTokenIs(token.RIGHT_CURLY_BRACKETS) { // trailing comma
+			break
+		}
+		p.nextToken()
+		key = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectedPeek(token.RIGHT_CURLY_BRACKETS) {
+		return nil
+	}
+	hash.EndToken = p.currentToken
+	return hash
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	functionLiteral := &ast.FunctionLiteral{
 		Token: p.currentToken,
@@ -308,36 +502,70 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	expression := &ast.CallExpression{
-		Token:     p.currentToken,
-		Function:  function,
-		Arguments: p.parseCallArguments(),
+		Token:    p.currentToken,
+		Function: function,
 	}
+	expression.Arguments = p.parseExpressionList(token.RIGHT_PARENTHESIS)
+	expression.EndToken = p.currentToken
 	return expression
 }
 
-func (p *Parser) parseCallArguments() []ast.Expression {
-	var arguments []ast.Expression
-	if p.peekTokenIs(token.RIGHT_PARENTHESIS) {
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{
+		Token: p.currentToken,
+	}
+	array.Elements = p.parseExpressionList(token.RIGHT_SQUARE_BRACKETS)
+	array.EndToken = p.currentToken
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{
+		Token: p.currentToken,
+		Left:  left,
+	}
+
+	p.nextToken()
+	expression.Index = p.parseExpression(LOWEST)
+
+	if !p.expectedPeek(token.RIGHT_SQUARE_BRACKETS) {
+		return nil
+	}
+	expression.EndToken = p.currentToken
+	return expression
+}
+
+// comma separated expressions up to the end token, used for call arguments and array elements
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	var list []ast.Expression
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return arguments
+		return list
 	}
 
 	p.nextToken()
-	arguments = append(arguments, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		arguments = append(arguments, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectedPeek(token.RIGHT_PARENTHESIS) {
+	if !p.expectedPeek(end) {
 		return nil
 	}
-	return arguments
+	return list
 }
 
 // Helpers
+// the semicolon ending a statement is optional. It is left to synchronize after an error,
+// since the current token may be the closing bracket of the enclosing block instead of the end of the statement.
+func (p *Parser) skipSemicolon() {
+	if p.peekTokenIs(token.SEMICOLON) && !p.panicking {
+		p.nextToken()
+	}
+}
 func (p *Parser) currentTokenIs(t token.TokenType) bool {
 	return p.currentToken.Type == t
 }
@@ -362,25 +590,23 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	//defer unTrace(trace("parseExpressionStatement"))
+	defer p.unTrace(p.trace("parseExpressionStatement", 0))
 	stmt := &ast.ExpressionStatement{
 		Token: p.currentToken,
 	}
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
-		p.nextToken()
-	}
+	p.skipSemicolon()
 
 	return stmt
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
-	//defer unTrace(trace("parseExpression"))
+	defer p.unTrace(p.trace("parseExpression", precedence))
 	prefix := p.prefixParseFns[p.currentToken.Type]
 	if prefix == nil {
-		p.noPrefixParseFnError(p.currentToken.Literal)
+		p.noPrefixParseFnError(p.currentToken)
 		return nil
 	}
 	leftExpression := prefix()
@@ -398,21 +624,41 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	return leftExpression
 }
 
-func (p *Parser) noPrefixParseFnError(t token.TokenLiteral) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+func (p *Parser) illegalTokenError(t token.Token) {
+	for _, d := range p.l.Errors() {
+		offset := d.Span.Start.Offset
+		if offset >= t.Span.Start.Offset && offset < t.Span.End.Offset {
+			p.addError(d)
+			return
+		}
+	}
+	p.addError(diagnostic.Errorf(diagnostic.ILLEGAL_CHARACTER, t.Span, "illegal token %q", t.Literal))
+}
+
+func (p *Parser) noPrefixParseFnError(t token.Token) {
+	d := diagnostic.Errorf(diagnostic.NO_PREFIX_PARSE_FN, t.Span, "no prefix parse function for %s found", t.Literal)
+	d.Actual = &t
+	d.Notes = []string{fmt.Sprintf("%s cannot start an expression", t.Type)}
+	p.addError(d)
 }
 
 var precedences = map[token.TokenType]int{
-	token.EQUAL:            EQUALS,
-	token.NOT_EQUAL:        EQUALS,
-	token.LT:               LESS_GRATER,
-	token.GT:               LESS_GRATER,
-	token.PLUS:             SUM,
-	token.MINUS:            SUM,
-	token.DIVIDE:           PRODUCT,
-	token.MULTIPLY:         PRODUCT,
-	token.LEFT_PARENTHESIS: CALL,
+	token.OR:                   OR,
+	token.AND:                  AND,
+	token.EQUAL:                EQUALS,
+	token.NOT_EQUAL:            EQUALS,
+	token.LT:                   LESS_GRATER,
+	token.GT:                   LESS_GRATER,
+	token.LT_EQUAL:             LESS_GRATER,
+	token.GT_EQUAL:             LESS_GRATER,
+	token.PLUS:                 SUM,
+	token.MINUS:                SUM,
+	token.DIVIDE:               PRODUCT,
+	token.MULTIPLY:             PRODUCT,
+	token.MODULES:              PRODUCT,
+	token.POWER:                EXPONENT,
+	token.LEFT_PARENTHESIS:     CALL,
+	token.LEFT_SQUARE_BRACKETS: INDEX,
 }
 
 func (p *Parser) peekPrecedence() int {
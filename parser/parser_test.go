@@ -2,9 +2,13 @@ package parser
 
 import (
 	"arcane/ast"
+	"arcane/diagnostic"
 	"arcane/lexer"
 	"arcane/token"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -118,7 +122,7 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	t.Errorf("Parser has %d errors", len(errors))
 
 	for _, err := range errors {
-		t.Errorf("Parser error, %q", err)
+		t.Errorf("Parser error, %q", err.Error())
 	}
 	t.FailNow()
 }
@@ -322,6 +326,10 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5<5;", 5, "<", 5},
 		{"5==5;", 5, "==", 5},
 		{"5!=5;", 5, "!=", 5},
+		{"5%5;", 5, "%", 5},
+		{"5**5;", 5, "**", 5},
+		{"5<=5;", 5, "<=", 5},
+		{"5>=5;", 5, ">=", 5},
 		{"a * b;", "a", "*", "b"},
 		{"false == false", false, "==", false},
 		{"false != true", false, "!=", true},
@@ -454,6 +462,82 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a + b % c - d",
+			"((a + (b % c)) - d)",
+		},
+		{
+			"a % b * c",
+			"((a % b) * c)",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a ** b ** c",
+			"(a ** (b ** c))",
+		},
+		{
+			"-a ** b",
+			"(-(a ** b))",
+		},
+		{
+			"a ** -b",
+			"(a ** (-b))",
+		},
+		{
+			"a ** b[0] ** f(c)",
+			"(a ** ((b[0]) ** f(c)))",
+		},
+		{
+			"a + b <= c * d == e >= f",
+			"(((a + b) <= (c * d)) == (e >= f))",
+		},
+		{
+			"1.5 * .5 - 2e3",
+			"((1.5 * .5) - 2e3)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a || b || c",
+			"((a || b) || c)",
+		},
+		{
+			"a == b && c != d",
+			"((a == b) && (c != d))",
+		},
+		{
+			"!a && !b == c",
+			"((!a) && ((!b) == c))",
+		},
+		{
+			"!(a || b) || a < b + 1",
+			"((!(a || b)) || (a < (b + 1)))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"f(x)[0]",
+			"(f(x)[0])",
+		},
+		{
+			"a + /* b */ c // d",
+			"(a + c)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -629,3 +713,597 @@ func TestCallFunctionExpression(t *testing.T) {
 		return
 	}
 }
+
+func TestNodeSpans(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedStart string
+		expectedEnd   string
+	}{
+		{"a + b", "1:1", "1:6"},
+		{"let x = -5;", "1:1", "1:11"},
+		{"  add(1,\n 2)", "1:3", "2:4"},
+		{"if (x) { y }\nelse { z }", "1:1", "2:11"},
+		{"fn(x) {\n x }", "1:1", "2:5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		span := program.Statements[0].Span()
+		if span.Start.String() != tt.expectedStart {
+			t.Errorf("%q: span start wrong. expected %s, got %s", tt.input, tt.expectedStart, span.Start)
+		}
+		if span.End.String() != tt.expectedEnd {
+			t.Errorf("%q: span end wrong. expected %s, got %s", tt.input, tt.expectedEnd, span.End)
+		}
+	}
+}
+
+func TestParserErrorPosition(t *testing.T) {
+	l := lexer.InitFile("main.arc", "let x = add(1,\n 2;")
+	p := Init(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	expected := "main.arc:2:3: error[E0001]: Expected next token to be ). got ;"
+	if p.Errors()[0].Error() != expected {
+		t.Errorf("wrong error. expected %q, got %q", expected, p.Errors()[0].Error())
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string
+	}{
+		{
+			"let = 5; let y = 10;",
+			[]string{"1:5: error[E0001]: Expected next token to be IDENT. got ="},
+			"let y = 10;",
+		},
+		{
+			"let x 5 + * 2; let y = x; y",
+			[]string{"1:7: error[E0001]: Expected next token to be =. got INT"},
+			"let y = x;y",
+		},
+		{
+			"if (x { y } let z = 1;",
+			[]string{"1:7: error[E0001]: Expected next token to be ). got {"},
+			"let z = 1;",
+		},
+		{
+			"let f = fn(x) { let = 1; x + }; f(2)",
+			[]string{
+				"1:21: error[E0001]: Expected next token to be IDENT. got =",
+				"1:30: error[E0002]: no prefix parse function for } found",
+			},
+			"let f = fn(x );f(2)",
+		},
+		{
+			"add(1, 2; return 3;",
+			[]string{"1:9: error[E0001]: Expected next token to be ). got ;"},
+			"return3;",
+		},
+		{
+			"} 1",
+			[]string{"1:1: error[E0002]: no prefix parse function for } found"},
+			"1",
+		},
+		{
+			`let f = fn() { let h = {"a": }; 1 }; f()`,
+			[]string{"1:30: error[E0002]: no prefix parse function for } found"},
+			"let f = fn( )1;f()",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q: expected %d errors, got %d: %v", tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, err := range errors {
+			if err.Error() != tt.expectedErrors[i] {
+				t.Errorf("%q: wrong error[%d]. expected %q, got %q", tt.input, i, tt.expectedErrors[i], err.Error())
+			}
+		}
+		if program.String() != tt.expectedStatements {
+			t.Errorf("%q: wrong statements. expected %q, got %q", tt.input, tt.expectedStatements, program.String())
+		}
+	}
+}
+
+func TestParserMaxErrors(t *testing.T) {
+	input := strings.Repeat("let = 1;\n", MAX_ERRORS*2)
+
+	l := lexer.Init(input)
+	p := Init(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != MAX_ERRORS+1 {
+		t.Fatalf("expected %d errors, got %d", MAX_ERRORS+1, len(errors))
+	}
+	if errors[MAX_ERRORS].Code != diagnostic.TOO_MANY_ERRORS {
+		t.Errorf("last error should be %s, got %s", diagnostic.TOO_MANY_ERRORS, errors[MAX_ERRORS].Code)
+	}
+
+	// exactly MAX_ERRORS errors are all reported, none is dropped
+	p = Init(lexer.Init(strings.Repeat("let = 1;\n", MAX_ERRORS)))
+	p.ParseProgram()
+
+	errors = p.Errors()
+	if len(errors) != MAX_ERRORS {
+		t.Fatalf("expected %d errors, got %d", MAX_ERRORS, len(errors))
+	}
+	for _, d := range errors {
+		if d.Code == diagnostic.TOO_MANY_ERRORS {
+			t.Errorf("unexpected %s with exactly %d errors: %s", diagnostic.TOO_MANY_ERRORS, MAX_ERRORS, d.Error())
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello \"world\"\n";`
+
+	l := lexer.Init(input)
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.StringLiteral, got %T", stmt.Expression)
+	}
+	if literal.Value != "hello \"world\"\n" {
+		t.Errorf("literal.Value is not %q, got %q", "hello \"world\"\n", literal.Value)
+	}
+	if literal.String() != `"hello \"world\"\n"` {
+		t.Errorf("literal.String() is not %q, got %q", `"hello \"world\"\n"`, literal.String())
+	}
+}
+
+func TestIllegalTokenErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`let s = "abc`, "1:9: error[E0005]: unterminated string literal"},
+		{`print("a\qb")`, `1:9: error[E0006]: invalid escape sequence \q`},
+		{`1 + @`, `1:5: error[E0007]: illegal character "@"`},
+		{"1 + /* 2", "1:5: error[E0008]: unterminated block comment"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != 1 {
+			t.Fatalf("%q: expected 1 error, got %d: %v", tt.input, len(p.Errors()), p.Errors())
+		}
+		if p.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("%q: wrong error. expected %q, got %q", tt.input, tt.expectedError, p.Errors()[0].Error())
+		}
+	}
+}
+
+func TestArrayLiteralExpression(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.Init(input)
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] does not satisfy ast.ExpressionStatement, got %T\n", program.Statements[0])
+	}
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.ArrayLiteral, got %T\n", stmt.Expression)
+	}
+	if len(array.Elements) != 3 {
+		t.Fatalf("array.Elements does not contain %d elements, got %d\n", 3, len(array.Elements))
+	}
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+
+	if span := array.Span(); span.Start.Column != 1 || span.End.Column != 18 {
+		t.Errorf("array span wrong. got %s-%s", span.Start, span.End)
+	}
+}
+
+func TestEmptyArrayLiteralExpression(t *testing.T) {
+	l := lexer.Init("[]")
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.ArrayLiteral, got %T\n", stmt.Expression)
+	}
+	if len(array.Elements) != 0 {
+		t.Fatalf("array.Elements is not empty, got %d\n", len(array.Elements))
+	}
+}
+
+func TestIndexExpression(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.Init(input)
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	index, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.IndexExpression, got %T\n", stmt.Expression)
+	}
+	if !testIdentifierLiteral(t, index.Left, "myArray") {
+		return
+	}
+	if !testInfixExpression(t, index.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestHashLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{`{"name": "x", 1: true}`, `{"name": "x", 1: true}`},
+		{`{}`, `{}`},
+		{`{"a": 1,}`, `{"a": 1}`},
+		{`{"one": 0 + 1, two: 10 - 8, true: 15 / 5}`, `{"one": (0 + 1), two: (10 - 8), true: (15 / 5)}`},
+		{`{"a": {"b": [1]}}["a"]`, `({"a": {"b": [1]}}["a"])`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements, got %d\n", 1, len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, program.String())
+		}
+	}
+}
+
+func TestHashLiteralPairs(t *testing.T) {
+	l := lexer.Init(`{"one": 1, "two": 2}`)
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.HashLiteral, got %T\n", stmt.Expression)
+	}
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("hash.Pairs does not contain %d pairs, got %d\n", 2, len(hash.Pairs))
+	}
+	for i, expected := range []struct {
+		key   string
+		value int64
+	}{{"one", 1}, {"two", 2}} {
+		key, ok := hash.Pairs[i].Key.(*ast.StringLiteral)
+		if !ok || key.Value != expected.key {
+			t.Errorf("pair[%d] key is not %q, got %s", i, expected.key, hash.Pairs[i].Key)
+		}
+		testIntegerLiteral(t, hash.Pairs[i].Value, expected.value)
+	}
+}
+
+func TestHashLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`{"a": 1, "b" 2}`, "1:14: error[E0001]: Expected next token to be :. got INT"},
+		{`{"a": 1 "b": 2}`, "1:9: error[E0001]: Expected next token to be }. got STRING"},
+		{`{ x }`, "1:5: error[E0001]: Expected next token to be :. got }"}, // a { in prefix position is always a hash
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != 1 {
+			t.Fatalf("%q: expected 1 error, got %d: %v", tt.input, len(p.Errors()), p.Errors())
+		}
+		if p.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("%q: wrong error. expected %q, got %q", tt.input, tt.expectedError, p.Errors()[0].Error())
+		}
+	}
+}
+
+func TestLogicalExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		left     interface{}
+		operator string
+		right    interface{}
+	}{
+		{"a && b", "a", "&&", "b"},
+		{"true || false", true, "||", false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.LogicalExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression does not satisfy ast.LogicalExpression, got %T", stmt.Expression)
+		}
+		if !testLiteralExpression(t, exp.Left, tt.left) {
+			return
+		}
+		if exp.Operator != tt.operator {
+			t.Fatalf("exp.Operator is not %s, got %s", tt.operator, exp.Operator)
+		}
+		if !testLiteralExpression(t, exp.Right, tt.right) {
+			return
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{".5", 0.5},
+		{"1e-9", 1e-9},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression does not satisfy ast.FloatLiteral, got %T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value is not %g, got %g", tt.expected, literal.Value)
+		}
+		if literal.String() != strings.TrimSuffix(tt.input, ";") {
+			t.Errorf("literal.String() is not %q, got %q", tt.input, literal.String())
+		}
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0x_7fff_ffff_ffff_ffff", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		l := lexer.Init(tt.input)
+		p := Init(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegralLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression does not satisfy ast.IntegralLiteral, got %T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value is not %d, got %d", tt.expected, literal.Value)
+		}
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() does not keep the spelling %q, got %q", tt.input, literal.String())
+		}
+	}
+}
+
+func TestBigIntegerLiteral(t *testing.T) {
+	input := "123_456_789_012_345_678_901_234_567_890"
+
+	l := lexer.Init(input)
+	p := Init(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegralLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression does not satisfy ast.IntegralLiteral, got %T", stmt.Expression)
+	}
+	if literal.Big == nil || literal.Big.String() != "123456789012345678901234567890" {
+		t.Errorf("literal.Big is wrong, got %v", literal.Big)
+	}
+	if literal.String() != input {
+		t.Errorf("literal.String() does not keep the spelling %q, got %q", input, literal.String())
+	}
+}
+
+func TestSetTrace(t *testing.T) {
+	var out strings.Builder
+	p := Init(lexer.Init("1 + 2"))
+	p.SetTrace(&out)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := `BEGIN parseExpressionStatement current=[INT "1" 1:1] peek=[+ "+" 1:3]
+	BEGIN parseExpression precedence=LOWEST current=[INT "1" 1:1] peek=[+ "+" 1:3]
+		BEGIN parseIntegerLiteral current=[INT "1" 1:1] peek=[+ "+" 1:3]
+		END parseIntegerLiteral current=[INT "1" 1:1] peek=[+ "+" 1:3]
+		BEGIN parseInfixExpression precedence=SUM current=[+ "+" 1:3] peek=[INT "2" 1:5]
+			BEGIN parseExpression precedence=SUM current=[INT "2" 1:5] peek=[EOF "" 1:6]
+				BEGIN parseIntegerLiteral current=[INT "2" 1:5] peek=[EOF "" 1:6]
+				END parseIntegerLiteral current=[INT "2" 1:5] peek=[EOF "" 1:6]
+			END parseExpression precedence=SUM current=[INT "2" 1:5] peek=[EOF "" 1:6]
+		END parseInfixExpression precedence=SUM current=[INT "2" 1:5] peek=[EOF "" 1:6]
+	END parseExpression precedence=LOWEST current=[INT "2" 1:5] peek=[EOF "" 1:6]
+END parseExpressionStatement current=[INT "2" 1:5] peek=[EOF "" 1:6]
+`
+	if out.String() != expected {
+		t.Errorf("wrong trace. expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+// the right side of ** is parsed one precedence below the operator, see TraceEvent
+func TestTraceRightAssociative(t *testing.T) {
+	var out strings.Builder
+	p := Init(lexer.Init("2 ** 3"))
+	p.SetTrace(&out)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"\t\tBEGIN parseInfixExpression precedence=EXPONENT current=[** \"**\" 1:3] peek=[INT \"3\" 1:6]\n",
+		"\t\t\tBEGIN parseExpression precedence=PREFIX current=[INT \"3\" 1:6] peek=[EOF \"\" 1:7]\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("trace does not contain %q. got\n%s", line, out.String())
+		}
+	}
+}
+
+func TestTraceEventJSON(t *testing.T) {
+	var events []TraceEvent
+	p := Init(lexer.Init("1 + 2"))
+	p.SetTraceFunc(func(e TraceEvent) { events = append(events, e) })
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	encoded, err := json.Marshal(events[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded["kind"] != "BEGIN" || decoded["function"] != "parseInfixExpression" || decoded["depth"] != 2.0 {
+		t.Errorf("wrong event. got %s", encoded)
+	}
+	if decoded["precedence"] != float64(SUM) {
+		t.Errorf("wrong precedence. expected %d, got %v", SUM, decoded["precedence"])
+	}
+	current, _ := decoded["current"].(map[string]interface{})
+	peek, _ := decoded["peek"].(map[string]interface{})
+	if current["literal"] != "+" || peek["literal"] != "2" {
+		t.Errorf("wrong tokens. got %s", encoded)
+	}
+
+	exit, _ := json.Marshal(events[len(events)-1])
+	if !strings.Contains(string(exit), `"kind":"END"`) || strings.Contains(string(exit), `"precedence"`) {
+		t.Errorf("wrong exit event of parseExpressionStatement. got %s", exit)
+	}
+}
+
+func TestSetTraceFunc(t *testing.T) {
+	var events []TraceEvent
+	p := Init(lexer.Init("a || b"))
+	p.SetTraceFunc(func(e TraceEvent) { events = append(events, e) })
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	var logical []TraceEvent
+	for _, e := range events {
+		if e.Function == "parseLogicalExpression" {
+			logical = append(logical, e)
+		}
+	}
+	if len(logical) != 2 {
+		t.Fatalf("expected an enter and an exit event for parseLogicalExpression, got %+v", logical)
+	}
+	enter, exit := logical[0], logical[1]
+	if enter.Kind != TRACE_ENTER || exit.Kind != TRACE_EXIT {
+		t.Errorf("wrong kinds. got %s and %s", enter.Kind, exit.Kind)
+	}
+	if enter.Precedence != OR || exit.Precedence != OR {
+		t.Errorf("wrong precedence. expected %d, got %d and %d", OR, enter.Precedence, exit.Precedence)
+	}
+	if enter.Depth != 2 || exit.Depth != 2 {
+		t.Errorf("wrong depth. expected 2, got %d and %d", enter.Depth, exit.Depth)
+	}
+	if enter.Current.Type != token.OR || enter.Peek.Literal != "b" {
+		t.Errorf("wrong tokens on enter. got %s and %s", enter.Current.Literal, enter.Peek.Literal)
+	}
+	if exit.Current.Literal != "b" || exit.Peek.Type != token.EOF {
+		t.Errorf("wrong tokens on exit. got %s and %s", exit.Current.Literal, exit.Peek.Literal)
+	}
+	if last := events[len(events)-1]; last.Depth != 0 || last.Kind != TRACE_EXIT {
+		t.Errorf("the trace does not end where it started. got %+v", last)
+	}
+}
+
+func TestTraceConcurrentParsers(t *testing.T) {
+	inputs := []string{"1 + 2 * 3", "-a * b", "!(x == y)", "a || b && c", "2 ** 3 ** 2"}
+	trace := func(input string) string {
+		var out strings.Builder
+		p := Init(lexer.Init(input))
+		p.SetTrace(&out)
+		p.ParseProgram()
+		return out.String()
+	}
+
+	expected := make([]string, len(inputs))
+	for i, input := range inputs {
+		expected[i] = trace(input)
+	}
+
+	got := make([]string, len(inputs))
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = trace(input)
+		}()
+	}
+	wg.Wait()
+
+	for i := range inputs {
+		if got[i] != expected[i] {
+			t.Errorf("trace of %q changed when parsed concurrently. expected\n%s\ngot\n%s", inputs[i], expected[i], got[i])
+		}
+	}
+}
@@ -2,37 +2,135 @@ package parser
 
 /**
 * Usage:
-	defer unTrace(trace("myFunction"))
+	defer p.unTrace(p.trace("myFunction", precedence))
 
 A deferred function's arguments are evaluated when the defer statement is evaluated.
 and the deferred function calls are executed in Last in First out order.
 
 deferred function: unTrace, and the arguments is trace.
+Nothing is traced until SetTrace or SetTraceFunc gives the parser somewhere to send the events,
+the state lives in the parser so parsers on different goroutines trace independently.
 */
 
 import (
+	"arcane/token"
 	"fmt"
+	"io"
 	"strings"
 )
 
-var traceLevel = 0 // starts with -1 so the first traced function has zero indentation/
 const traceIndentPlaceholder = "\t"
 
-func indentLevel() string {
-	return strings.Repeat(traceIndentPlaceholder, traceLevel-1)
+// TraceKind tells whether a parse function is entered or left.
+type TraceKind int
+
+const (
+	TRACE_ENTER TraceKind = iota
+	TRACE_EXIT
+)
+
+func (k TraceKind) String() string {
+	if k == TRACE_EXIT {
+		return "END"
+	}
+	return "BEGIN"
 }
-func printTrace(msg string) {
-	fmt.Printf("%s%s\n", indentLevel(), msg)
+
+// MarshalText writes the kind as BEGIN or END in the JSON of the events.
+func (k TraceKind) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+// TraceEvent is sent when a parse function is entered and when it is left.
+//
+// The Precedence of parseExpression is the one it parses at, of an infix or logical expression the one of its
+// operator. The right side of the right-associative ** is parsed one below EXPONENT, so in the trace of 2 ** 3
+// parseInfixExpression has precedence=EXPONENT and the parseExpression of 3 under it precedence=PREFIX.
+type TraceEvent struct {
+	Kind       TraceKind   `json:"kind"`
+	Function   string      `json:"function"`
+	Depth      int         `json:"depth"`   // 0 for the outermost traced function
+	Current    token.Token `json:"current"` // the current and peek tokens when the event is sent
+	Peek       token.Token `json:"peek"`
+	Precedence int         `json:"precedence,omitempty"` // 0 for functions that have none
+}
+
+var precedenceNames = map[int]string{
+	LOWEST:      "LOWEST",
+	OR:          "OR",
+	AND:         "AND",
+	EQUALS:      "EQUALS",
+	LESS_GRATER: "LESS_GRATER",
+	SUM:         "SUM",
+	PRODUCT:     "PRODUCT",
+	PREFIX:      "PREFIX",
+	EXPONENT:    "EXPONENT",
+	CALL:        "CALL",
+	INDEX:       "INDEX",
+}
+
+// String formats the event as a line of the text trace, ex.
+//
+//	BEGIN parseExpression precedence=SUM current=[INT "2" 1:5] peek=[EOF "" 1:6]
+//
+// without the indentation.
+func (e TraceEvent) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s %s", e.Kind, e.Function)
+	if e.Precedence != 0 {
+		name, ok := precedenceNames[e.Precedence]
+		if !ok {
+			name = fmt.Sprint(e.Precedence)
+		}
+		fmt.Fprintf(&out, " precedence=%s", name)
+	}
+	fmt.Fprintf(&out, " current=%s peek=%s", traceToken(e.Current), traceToken(e.Peek))
+	return out.String()
+}
+
+func traceToken(t token.Token) string {
+	return fmt.Sprintf("[%s %q %s]", t.Type, t.Literal, t.Span)
+}
+
+// SetTrace writes a line to w, indented by depth, every time a parse function is entered or left.
+// nil turns tracing off.
+func (p *Parser) SetTrace(w io.Writer) {
+	if w == nil {
+		p.tracer = nil
+		return
+	}
+	p.tracer = func(e TraceEvent) {
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat(traceIndentPlaceholder, e.Depth), e)
+	}
+}
+
+// SetTraceFunc calls fn with the events themselves instead of writing them, nil turns tracing off.
+func (p *Parser) SetTraceFunc(fn func(TraceEvent)) {
+	p.tracer = fn
+}
+
+func (p *Parser) traceEvent(kind TraceKind, function string, precedence int) TraceEvent {
+	return TraceEvent{
+		Kind:       kind,
+		Function:   function,
+		Depth:      p.traceDepth,
+		Current:    p.currentToken,
+		Peek:       p.peekToken,
+		Precedence: precedence,
+	}
 }
-func incrementIndent() { traceLevel += 1 }
-func decrementIndent() { traceLevel -= 1 }
 
-func trace(msg string) string {
-	incrementIndent()
-	printTrace("BEGIN: " + msg)
-	return msg
+func (p *Parser) trace(function string, precedence int) TraceEvent {
+	if p.tracer == nil {
+		return TraceEvent{}
+	}
+	enter := p.traceEvent(TRACE_ENTER, function, precedence)
+	p.tracer(enter)
+	p.traceDepth += 1
+	return enter
 }
-func unTrace(msg string) {
-	printTrace("END: " + msg)
-	decrementIndent()
+func (p *Parser) unTrace(enter TraceEvent) {
+	if p.tracer == nil {
+		return
+	}
+	p.traceDepth -= 1
+	p.tracer(p.traceEvent(TRACE_EXIT, enter.Function, enter.Precedence))
 }
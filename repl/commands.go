@@ -0,0 +1,109 @@
+package repl
+
+import (
+	"arcane/ast"
+	"arcane/lexer"
+	"arcane/object"
+	"arcane/parser"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Meta-commands start with a colon and are handled by the REPL itself instead of being evaluated.
+
+type command struct {
+	name  string
+	args  string // shown in the help and when the arguments are wrong
+	help  string
+	noArg bool
+	run   func(s *session, arg string) bool // false when the arguments are wrong
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: ":tokens", args: "<source>", help: "print the tokens of the source", run: (*session).tokens},
+		{name: ":ast", args: "<source>", help: "print the syntax tree of the source", run: (*session).ast},
+		{name: ":trace", args: "on|off", help: "print the parse functions as the input is parsed", run: (*session).trace},
+		{name: ":load", args: "<file.arc>", help: "evaluate a file in the session", run: (*session).load},
+		{name: ":reset", help: "forget every binding", run: (*session).reset, noArg: true},
+		{name: ":help", help: "print this help", run: (*session).help, noArg: true},
+	}
+}
+
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ":")
+}
+
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if (arg == "") != c.noArg || !c.run(s, arg) {
+			fmt.Fprintf(s.out, "usage: %s\n", strings.TrimSpace(c.name+" "+c.args))
+		}
+		return
+	}
+	fmt.Fprintf(s.out, "unknown command %s, :help lists the commands\n", name)
+}
+
+func (s *session) tokens(source string) bool {
+	l := lexer.Init(source)
+	_ = lexer.Fprint(s.out, l)
+	s.renderErrors(source, l.Errors())
+	return true
+}
+
+func (s *session) ast(source string) bool {
+	p := parser.Init(lexer.Init(source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		s.renderErrors(source, p.Errors())
+		return true
+	}
+	_ = ast.Fprint(s.out, program)
+	return true
+}
+
+func (s *session) trace(arg string) bool {
+	switch arg {
+	case "on":
+		s.tracing = true
+	case "off":
+		s.tracing = false
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *session) load(filename string) bool {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return true
+	}
+	s.evaluate(filename, string(source))
+	return true
+}
+
+func (s *session) reset(string) bool {
+	s.env = object.NewEnvironment()
+	_, _ = io.WriteString(s.out, "every binding is gone\n")
+	return true
+}
+
+func (s *session) help(string) bool {
+	for _, c := range commands {
+		fmt.Fprintf(s.out, "%-20s %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+	return true
+}
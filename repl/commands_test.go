@@ -0,0 +1,46 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.arc")
+	if err := os.WriteFile(file, []byte("let double = fn(x) { x * 2 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string // contained in the output
+	}{
+		{":tokens let x = 1;\n", "1:5      IDENT      \"x\"\n"},
+		{":tokens 1 @\n", "error[E0007]: illegal character \"@\""},
+		{":ast -a\n", "    Expression: PrefixExpression 1:1 `(-a)`\n"},
+		{":ast let = 1\n", "error[E0001]"},
+		{":load " + file + "\ndouble(21)\n", ">>> >>> 42\n"},
+		{":load " + file + "x\n", "no such file or directory"},
+		{"let x = 1;\n:reset\nx\n", "every binding is gone\n>>> ERROR: identifier not found: x\n"},
+		{":trace on\n1 + 2\n", "\tBEGIN parseInfixExpression precedence=SUM current=[+ \"+\" 1:3]"},
+		{":trace on\n:trace off\n1\n", ">>> >>> >>> 1\n"},
+		{":trace maybe\n", "usage: :trace on|off\n"},
+		{":tokens\n", "usage: :tokens <source>\n"},
+		{":reset now\n", "usage: :reset\n"},
+		{":help\n", ":load <file.arc>     evaluate a file in the session\n"},
+		{":nope\n", "unknown command :nope, :help lists the commands\n"},
+		{"[1,\n:help\n]\n", "no prefix parse function for : found"}, // inside an input a colon line is not a command
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		run(newPlainReader(strings.NewReader(tt.input), &out, nil), &out)
+
+		if !strings.Contains(out.String(), tt.expected) {
+			t.Errorf("wrong output for %q. expected %q in\n%s", tt.input, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"arcane/token"
+	"sort"
+	"strings"
+)
+
+// completions returns the meta-commands starting with the prefix when it starts with a colon,
+// otherwise the keywords and the names bound in the session, sorted.
+func (s *session) completions(prefix string) []string {
+	var words []string
+	if strings.HasPrefix(prefix, ":") {
+		for _, c := range commands {
+			words = append(words, c.name)
+		}
+	} else {
+		words = append(token.Keywords(), s.env.Names()...)
+	}
+
+	var candidates []string
+	seen := map[string]bool{}
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) && !seen[word] {
+			seen[word] = true
+			candidates = append(candidates, word)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// completeWord completes the word before the cursor: a single candidate is inserted, several ones are
+// completed up to their common prefix, or listed below the line when they have nothing more in common.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+
+	start := e.cursor
+	for start > 0 && isWordRune(e.line[start-1]) {
+		start--
+	}
+	if start > 0 && e.line[start-1] == ':' && strings.TrimSpace(string(e.line[:start-1])) == "" {
+		start-- // a meta-command
+	}
+	prefix := string(e.line[start:e.cursor])
+	if prefix == "" {
+		return
+	}
+
+	candidates := e.complete(prefix)
+	if len(candidates) == 0 {
+		return
+	}
+	common := candidates[0]
+	for _, candidate := range candidates[1:] {
+		common = commonPrefix(common, candidate)
+	}
+
+	if len(common) > len(prefix) {
+		for _, r := range strings.TrimPrefix(common, prefix) {
+			e.insert(r)
+		}
+		return
+	}
+	if len(candidates) > 1 {
+		_, _ = e.out.Write([]byte("\r\n" + strings.Join(candidates, "  ") + "\r\n"))
+	}
+}
+
+func commonPrefix(a string, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	for i > 0 && i < len(a) && !isRuneStart(a[i]) { // do not split a rune
+		i--
+	}
+	return a[:i]
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
@@ -0,0 +1,91 @@
+package repl
+
+import (
+	"arcane/object"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompletions(t *testing.T) {
+	s := &session{env: object.NewEnvironment()}
+	s.env.Set("length", &object.Integer{Value: 1})
+	s.env.Set("let_me", &object.Integer{Value: 2})
+	s.env.Set("fib", &object.Integer{Value: 3})
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"le", []string{"length", "let", "let_me"}},
+		{"f", []string{"false", "fib", "fn"}},
+		{"ret", []string{"return"}},
+		{"zz", nil},
+		{":", []string{":ast", ":help", ":load", ":reset", ":tokens", ":trace"}},
+		{":t", []string{":tokens", ":trace"}},
+	}
+
+	for _, tt := range tests {
+		got := s.completions(tt.prefix)
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("completions(%q) wrong. expected %v, got %v", tt.prefix, tt.expected, got)
+		}
+	}
+}
+
+func TestTabCompletion(t *testing.T) {
+	words := []string{":tokens", ":trace", "counter", "count", "fib", "fn", "false", "πr", "πx"}
+	complete := func(prefix string) []string {
+		var candidates []string
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				candidates = append(candidates, word)
+			}
+		}
+		return candidates
+	}
+
+	tests := []struct {
+		keys           string
+		expectedLine   string
+		expectedOutput string // contained in the output
+	}{
+		{"fi\t(1)\r", "fib(1)", ""},
+		{"let x = coun\t\r", "let x = count", ""},
+		{"coun\t\t\r", "count", "\r\ncounter  count\r\n"},
+		{"f\t\r", "f", "\r\nfib  fn  false\r\n"},
+		{":tr\t on\r", ":trace on", ""},
+		{":t\t\r", ":t", "\r\n:tokens  :trace\r\n"},
+		{"x:tr\t\r", "x:tr", ""}, // only a whole line is a meta-command
+		{"π\t\r", "π", "\r\nπr  πx\r\n"},
+		{"zz\t\r", "zz", ""},
+		{"fib" + left + left + "\t\r", "fib", ""}, // completes the word before the cursor only
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := newLineEditor(nil, strings.NewReader(tt.keys), &out, &history{})
+		e.complete = complete
+
+		line, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", tt.keys, err)
+		}
+		if line != tt.expectedLine+"\n" {
+			t.Errorf("%q: wrong line. expected %q, got %q", tt.keys, tt.expectedLine+"\n", line)
+		}
+		if !strings.Contains(out.String(), tt.expectedOutput) {
+			t.Errorf("%q: expected %q in the output %q", tt.keys, tt.expectedOutput, out.String())
+		}
+	}
+}
+
+// names bound while the REPL runs can be completed right away
+func TestRunCompletesBoundNames(t *testing.T) {
+	var out bytes.Buffer
+	run(newLineEditor(nil, strings.NewReader("let answer = 42;\rans\t\r\x04"), &out, &history{}), &out)
+
+	if !strings.Contains(out.String(), "\r\n42\n") {
+		t.Errorf("ans was not completed to answer: %q", out.String())
+	}
+}
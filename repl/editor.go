@@ -0,0 +1,394 @@
+package repl
+
+import (
+	"arcane/highlight"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// The line editor reads the keys one by one with the terminal in raw mode and redraws the line as it changes:
+//
+//	Left/Right, Ctrl-B/Ctrl-F         move by char       Alt-B/Alt-F, Ctrl-Left/Right  move by word
+//	Home/End, Ctrl-A/Ctrl-E           start/end of line  Up/Down, Ctrl-P/Ctrl-N        browse the history
+//	Backspace, Delete, Ctrl-D         delete a char      Ctrl-W, Alt-Backspace, Alt-D  delete a word
+//	Ctrl-U/Ctrl-K                     delete to the start/end of the line
+//	Ctrl-R                            search the history, Ctrl-R again for older matches, Ctrl-G to give up
+//	Ctrl-L                            clear the screen
+//	Tab                               complete a keyword, a bound name or a meta-command
+//	Ctrl-C                            drop the input      Ctrl-D on an empty line      end of input
+
+// errInterrupted is returned when Ctrl-C drops the line being typed.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads the input of the REPL one line at a time, after showing the prompt.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// keys that are not a single rune, decoded from escape sequences
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyWordRight
+	keyWordLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyDeleteWordBack
+	keyDeleteWord
+	keyUnknown
+)
+
+func ctrl(key rune) rune { return key & 0x1f }
+
+const (
+	keyEnter     = '\r'
+	keyBackspace = 0x7f
+	keyEscape    = 0x1b
+)
+
+type lineEditor struct {
+	terminal *os.File // put in raw mode while a line is read, nil when the input is not a terminal (tests)
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+
+	prompt string
+	line   []rune
+	cursor int
+	browse int    // index of the history entry shown, len(history.entries) for the line being typed
+	typed  []rune // the line being typed, kept while browsing the history
+	unread rune   // a key read but not handled yet, 0 if none
+
+	complete func(prefix string) []string // the words Tab can complete the prefix with, nil for none
+	color    bool                         // highlight the line as it is typed
+}
+
+func newLineEditor(terminal *os.File, in io.Reader, out io.Writer, h *history) *lineEditor {
+	return &lineEditor{terminal: terminal, in: bufio.NewReader(in), out: out, history: h}
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.terminal != nil {
+		if state, err := makeRaw(e.terminal.Fd()); err == nil {
+			defer func() { _ = restoreTerminal(e.terminal.Fd(), state) }()
+		}
+	}
+
+	e.prompt, e.line, e.cursor = prompt, nil, 0
+	e.browse, e.typed = len(e.history.entries), nil
+	e.refresh()
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, '\n':
+			return e.accept(), nil
+		case ctrl('C'):
+			_, _ = io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(e.line) == 0 {
+				return "", io.EOF
+			}
+			e.delete(e.cursor, e.cursor+1)
+		case ctrl('R'):
+			submit, err := e.reverseSearch()
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				return e.accept(), nil
+			}
+		default:
+			e.edit(key)
+		}
+		e.refresh()
+	}
+}
+
+// accept ends the line and records it in the history
+func (e *lineEditor) accept() string {
+	line := string(e.line)
+	_, _ = io.WriteString(e.out, "\r\n")
+	e.history.add(line)
+	return line + "\n"
+}
+
+// edit applies a key that moves the cursor or changes the line
+func (e *lineEditor) edit(key rune) {
+	switch key {
+	case keyLeft, ctrl('B'):
+		if e.cursor > 0 {
+			e.cursor -= 1
+		}
+	case keyRight, ctrl('F'):
+		if e.cursor < len(e.line) {
+			e.cursor += 1
+		}
+	case keyWordLeft:
+		e.cursor = e.wordStart()
+	case keyWordRight:
+		e.cursor = e.wordEnd()
+	case keyHome, ctrl('A'):
+		e.cursor = 0
+	case keyEnd, ctrl('E'):
+		e.cursor = len(e.line)
+	case keyUp, ctrl('P'):
+		e.showHistory(e.browse - 1)
+	case keyDown, ctrl('N'):
+		e.showHistory(e.browse + 1)
+	case keyBackspace, ctrl('H'):
+		e.delete(e.cursor-1, e.cursor)
+	case keyDelete:
+		e.delete(e.cursor, e.cursor+1)
+	case ctrl('W'), keyDeleteWordBack:
+		e.delete(e.wordStart(), e.cursor)
+	case keyDeleteWord:
+		e.delete(e.cursor, e.wordEnd())
+	case ctrl('U'):
+		e.delete(0, e.cursor)
+	case ctrl('K'):
+		e.delete(e.cursor, len(e.line))
+	case ctrl('L'):
+		_, _ = io.WriteString(e.out, "\x1b[H\x1b[2J")
+	case '\t':
+		e.completeWord()
+	default:
+		if unicode.IsPrint(key) {
+			e.insert(key)
+		}
+	}
+}
+
+func (e *lineEditor) insert(key rune) {
+	e.line = append(e.line, 0)
+	copy(e.line[e.cursor+1:], e.line[e.cursor:])
+	e.line[e.cursor] = key
+	e.cursor += 1
+}
+
+// delete removes the runes from start up to end, clamped to the line, and leaves the cursor at start
+func (e *lineEditor) delete(start int, end int) {
+	start, end = max(start, 0), min(end, len(e.line))
+	if start >= end {
+		return
+	}
+	e.line = append(e.line[:start], e.line[end:]...)
+	e.cursor = start
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// start of the word before the cursor, skipping the separators right before it
+func (e *lineEditor) wordStart() int {
+	i := e.cursor
+	for i > 0 && !isWordRune(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// end of the word after the cursor, skipping the separators right after it
+func (e *lineEditor) wordEnd() int {
+	i := e.cursor
+	for i < len(e.line) && !isWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && isWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+// showHistory replaces the line with the history entry at index, len(history.entries) is the line being typed
+func (e *lineEditor) showHistory(index int) {
+	if index < 0 || index > len(e.history.entries) {
+		return
+	}
+	if e.browse == len(e.history.entries) {
+		e.typed = append([]rune(nil), e.line...)
+	}
+
+	e.browse = index
+	if index == len(e.history.entries) {
+		e.line = append([]rune(nil), e.typed...)
+	} else {
+		e.line = []rune(e.history.entries[index])
+	}
+	e.cursor = len(e.line)
+}
+
+// reverseSearch shows the newest history entry containing the query as it is typed. It returns true when
+// Enter runs the match, any other key that is not part of the search puts the match in the line for editing.
+func (e *lineEditor) reverseSearch() (bool, error) {
+	original, originalCursor := e.line, e.cursor
+	var query []rune
+	match := len(e.history.entries)
+	failing := false
+
+	for {
+		found := ""
+		if match < len(e.history.entries) {
+			found = e.history.entries[match]
+		}
+		status := "reverse-i-search"
+		if failing {
+			status = "failing " + status
+		}
+		fmt.Fprintf(e.out, "\r\x1b[K(%s)`%s': %s", status, string(query), found)
+
+		key, err := e.readKey()
+		if err != nil {
+			return false, err
+		}
+
+		next := -1
+		switch {
+		case key == ctrl('R'):
+			next = e.history.search(string(query), match-1)
+		case key == keyBackspace || key == ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			next = e.history.search(string(query), len(e.history.entries)-1)
+		case key == ctrl('C') || key == ctrl('G'):
+			e.line, e.cursor = original, originalCursor
+			return false, nil
+		case key == keyEnter || key == '\n':
+			e.useMatch(match)
+			return true, nil
+		case key >= 0 && unicode.IsPrint(key):
+			query = append(query, key)
+			next = e.history.search(string(query), match)
+		default:
+			e.useMatch(match)
+			e.unread = key
+			return false, nil
+		}
+
+		failing = next == -1
+		if !failing {
+			match = next
+		}
+	}
+}
+
+func (e *lineEditor) useMatch(match int) {
+	if match < len(e.history.entries) {
+		e.line = []rune(e.history.entries[match])
+		e.cursor = len(e.line)
+	}
+}
+
+// refresh redraws the line and puts the cursor back in place
+func (e *lineEditor) refresh() {
+	var screen strings.Builder
+	screen.WriteString("\r")
+	screen.WriteString(e.prompt)
+	if e.color {
+		screen.WriteString(highlight.ANSI(string(e.line)))
+	} else {
+		screen.WriteString(string(e.line))
+	}
+	screen.WriteString("\x1b[K") // clear what is left of a longer line
+	screen.WriteString("\r")
+	if column := len([]rune(e.prompt)) + e.cursor; column > 0 {
+		fmt.Fprintf(&screen, "\x1b[%dC", column)
+	}
+	_, _ = io.WriteString(e.out, screen.String())
+}
+
+// readKey reads a key, decoding the escape sequences of the special keys
+func (e *lineEditor) readKey() (rune, error) {
+	if key := e.unread; key != 0 {
+		e.unread = 0
+		return key, nil
+	}
+
+	key, _, err := e.in.ReadRune()
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return keyUnknown, err
+	}
+	switch next {
+	case keyBackspace:
+		return keyDeleteWordBack, nil
+	case 'b':
+		return keyWordLeft, nil
+	case 'f':
+		return keyWordRight, nil
+	case 'd':
+		return keyDeleteWord, nil
+	case '[', 'O':
+		return e.readEscapeSequence()
+	}
+	return keyUnknown, nil
+}
+
+// reads the rest of ESC [ or ESC O: parameters, then a final char from @ to ~
+func (e *lineEditor) readEscapeSequence() (rune, error) {
+	var parameters strings.Builder
+	for {
+		ch, _, err := e.in.ReadRune()
+		if err != nil {
+			return keyUnknown, err
+		}
+		if ch < '@' || ch > '~' {
+			parameters.WriteRune(ch)
+			continue
+		}
+
+		word := strings.HasSuffix(parameters.String(), ";5") // with Ctrl
+		switch ch {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			if word {
+				return keyWordRight, nil
+			}
+			return keyRight, nil
+		case 'D':
+			if word {
+				return keyWordLeft, nil
+			}
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch parameters.String() {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			}
+		}
+		return keyUnknown, nil
+	}
+}
@@ -0,0 +1,135 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	up        = "\x1b[A"
+	down      = "\x1b[B"
+	right     = "\x1b[C"
+	left      = "\x1b[D"
+	home      = "\x1b[H"
+	end       = "\x1b[F"
+	deleteKey = "\x1b[3~"
+	ctrlLeft  = "\x1b[1;5D"
+	altD      = "\x1bd"
+)
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"let x = 1;\r", "let x = 1;"},
+		{"1 + 3" + left + left + left + "2 \x7f\x7f- \r", "1 - + 3"},
+		{"bc" + home + "a" + end + "d\r", "abcd"},
+		{"abc\x01\x05\x02\x02\x06x\r", "abxc"}, // Ctrl-A, Ctrl-E, Ctrl-B, Ctrl-F
+		{"abc" + home + deleteKey + "\x04\r", "c"},
+		{"let add = fn\x17\x17x\r", "let x"}, // Ctrl-W
+		{"let add = fn" + ctrlLeft + ctrlLeft + altD + "sum\r", "let sum = fn"},
+		{"abc" + left + "\x15\r", "c"},  // Ctrl-U
+		{"abc" + left + "\x0b\r", "ab"}, // Ctrl-K
+		{"π = 1" + home + right + "ι\r", "πι = 1"},
+		{"a\tb\x1b[Zc\r", "abc"}, // unknown keys are ignored
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := newLineEditor(nil, strings.NewReader(tt.keys), &out, &history{})
+
+		line, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", tt.keys, err)
+		}
+		if line != tt.expected+"\n" {
+			t.Errorf("%q: wrong line. expected %q, got %q", tt.keys, tt.expected+"\n", line)
+		}
+	}
+}
+
+func TestLineEditorEndOfInput(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected error
+	}{
+		{"\x04", io.EOF},
+		{"abc\x03", errInterrupted},
+		{"abc", io.EOF},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := newLineEditor(nil, strings.NewReader(tt.keys), &out, &history{})
+
+		if _, err := e.readLine(PROMPT); err != tt.expected {
+			t.Errorf("%q: wrong error. expected %v, got %v", tt.keys, tt.expected, err)
+		}
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	h := &history{entries: []string{"let x = 1;", "let y = 2;", "x + y"}}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{up + "\r", "x + y"},
+		{up + up + up + up + "\r", "let x = 1;"},
+		{"draft" + up + up + down + down + "\r", "draft"},
+		{up + " * 2\r", "x + y * 2"},
+		{"\x12let\r", "let y = 2;"},                    // Ctrl-R
+		{"\x12let\x12\r", "let x = 1;"},                // Ctrl-R again for an older match
+		{"\x12let\x12\x12\r", "let x = 1;"},            // nothing older, the match stays
+		{"\x12y =" + end + " + 1\r", "let y = 2; + 1"}, // End leaves the search to edit the match
+		{"keep\x12zzz\x07\r", "keep"},                  // Ctrl-G gives up
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := newLineEditor(nil, strings.NewReader(tt.keys), &out, &history{entries: append([]string(nil), h.entries...)})
+
+		line, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", tt.keys, err)
+		}
+		if line != tt.expected+"\n" {
+			t.Errorf("%q: wrong line. expected %q, got %q", tt.keys, tt.expected+"\n", line)
+		}
+	}
+}
+
+func TestLineEditorRefresh(t *testing.T) {
+	var out bytes.Buffer
+	e := newLineEditor(nil, strings.NewReader("ab"+left+"\r"), &out, &history{})
+	if _, err := e.readLine(PROMPT); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\r>>> \x1b[K\r\x1b[4C" +
+		"\r>>> a\x1b[K\r\x1b[5C" +
+		"\r>>> ab\x1b[K\r\x1b[6C" +
+		"\r>>> ab\x1b[K\r\x1b[5C" +
+		"\r\n"
+	if out.String() != expected {
+		t.Errorf("wrong screen output. expected %q, got %q", expected, out.String())
+	}
+}
+
+// the editor reads the REPL input, lines entered are added to the history
+func TestRunWithLineEditor(t *testing.T) {
+	h := &history{}
+	var out bytes.Buffer
+	run(newLineEditor(nil, strings.NewReader("let x = 20;\r"+up+"\x15x * 2\r\x04"), &out, h), &out)
+
+	if !strings.HasSuffix(out.String(), "40\n\r>>> \x1b[K\r\x1b[4C\n") {
+		t.Errorf("wrong output %q", out.String())
+	}
+	if strings.Join(h.entries, "|") != "let x = 20;|x * 2" {
+		t.Errorf("wrong history %q", h.entries)
+	}
+}
@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lines kept in the history, older ones are dropped
+const maxHistory = 1000
+
+// history holds the lines entered in the REPL, oldest first, and appends every new line to its file
+// so the next session can recall it.
+type history struct {
+	entries []string
+	path    string // empty to keep the history in memory only
+}
+
+// historyPath is the history file under the user config dir, ex. ~/.config/arcane/history, or empty without one.
+func historyPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "arcane", "history")
+}
+
+// loadHistory reads the history file, a missing or unreadable file is an empty history.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		h.entries = append(h.entries, scanner.Text())
+	}
+	_ = file.Close()
+
+	if len(h.entries) > maxHistory { // keep the file from growing forever
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		_ = os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+	}
+	return h
+}
+
+// add records a line, blank lines and repeats of the last line are skipped.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	_, _ = file.WriteString(line + "\n")
+	_ = file.Close()
+}
+
+// search returns the index of the newest entry containing query at or before index from, or -1.
+func (h *history) search(query string, from int) int {
+	if from >= len(h.entries) {
+		from = len(h.entries) - 1
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
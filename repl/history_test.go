@@ -0,0 +1,66 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arcane", "history")
+
+	h := loadHistory(path)
+	h.add("let x = 1;")
+	h.add("let x = 1;") // repeated
+	h.add("   ")        // blank
+	h.add("x * 2")
+
+	loaded := loadHistory(path)
+	if strings.Join(loaded.entries, "|") != "let x = 1;|x * 2" {
+		t.Errorf("wrong history loaded from the file: %q", loaded.entries)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	var lines []string
+	for i := 0; i < maxHistory+10; i++ {
+		lines = append(lines, strconv.Itoa(i))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := loadHistory(path)
+	if len(h.entries) != maxHistory || h.entries[0] != "10" {
+		t.Fatalf("wrong history kept: %d entries starting with %q", len(h.entries), h.entries[0])
+	}
+	if reloaded := loadHistory(path); len(reloaded.entries) != maxHistory {
+		t.Errorf("the file was not trimmed, %d entries", len(reloaded.entries))
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	h := &history{entries: []string{"let a = 1;", "b", "let c = 3;"}}
+
+	tests := []struct {
+		query    string
+		from     int
+		expected int
+	}{
+		{"let", 2, 2},
+		{"let", 1, 0},
+		{"let", 10, 2},
+		{"zzz", 2, -1},
+		{"", 1, 1},
+		{"let", -1, -1},
+	}
+
+	for _, tt := range tests {
+		if got := h.search(tt.query, tt.from); got != tt.expected {
+			t.Errorf("search(%q, %d) wrong. expected %d, got %d", tt.query, tt.from, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package repl
+
+import (
+	"arcane/diagnostic"
+	"arcane/lexer"
+	"arcane/token"
+)
+
+// tokens after which a statement cannot end
+var continuations = map[token.TokenType]bool{
+	token.ASSIGN:    true,
+	token.PLUS:      true,
+	token.MINUS:     true,
+	token.MULTIPLY:  true,
+	token.DIVIDE:    true,
+	token.MODULES:   true,
+	token.POWER:     true,
+	token.NOT:       true,
+	token.EQUAL:     true,
+	token.NOT_EQUAL: true,
+	token.GT:        true,
+	token.LT:        true,
+	token.GT_EQUAL:  true,
+	token.LT_EQUAL:  true,
+	token.AND:       true,
+	token.OR:        true,
+	token.COMMA:     true,
+	token.COLON:     true,
+	token.DOT:       true,
+	token.FUNCTION:  true,
+	token.LET:       true,
+	token.IF:        true,
+	token.ELSE:      true,
+	token.RETURN:    true,
+}
+
+// incomplete reports whether the input needs more lines before it can be evaluated: a bracket is still open,
+// it ends with an operator, or a string or block comment is not closed. Any other mistake is left for the parser.
+func incomplete(input string) bool {
+	l := lexer.Init(input)
+	depth := 0
+	last := token.EOF
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LEFT_PARENTHESIS, token.LEFT_CURLY_BRACKETS, token.LEFT_SQUARE_BRACKETS:
+			depth += 1
+		case token.RIGHT_PARENTHESIS, token.RIGHT_CURLY_BRACKETS, token.RIGHT_SQUARE_BRACKETS:
+			depth -= 1
+		}
+		last = tok.Type
+	}
+
+	for _, d := range l.Errors() {
+		if d.Code == diagnostic.UNTERMINATED_STRING || d.Code == diagnostic.UNTERMINATED_COMMENT {
+			return true
+		}
+	}
+	return depth > 0 || continuations[last]
+}
@@ -0,0 +1,36 @@
+package repl
+
+import "testing"
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2\n", false},
+		{"\n", false},
+		{"let add = fn(x, y) {\n", true},
+		{"let add = fn(x, y) {\n x + y\n}\n", false},
+		{"add(1,\n", true},
+		{"[1, 2\n", true},
+		{"{\"a\": 1\n", true},
+		{"1 +\n", true},
+		{"x &&\n", true},
+		{"let x =\n", true},
+		{"let\n", true},
+		{"return\n", true},
+		{"if (x) { 1 } else\n", true},
+		{"\"abc\n", true},
+		{"\"abc\"\n", false},
+		{"/* a\n", true},
+		{"// a (\n", false},
+		{"1 )\n", false}, // unbalanced the other way, the parser reports it
+		{"1 @\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) wrong. expected %t, got %t", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -1,36 +1,223 @@
 package repl
 
 import (
+	"arcane/diagnostic"
+	"arcane/evaluator"
+	"arcane/highlight"
 	"arcane/lexer"
+	"arcane/object"
 	"arcane/parser"
 	"bufio"
 	"fmt"
 	"io"
-	"log"
+	"os"
+	"os/signal"
+	"strings"
 )
 
+const PROMPT = ">>> "
+const CONTINUATION_PROMPT = "... " // the input goes on, see incomplete
+
+// Start reads and evaluates the input until it ends (Ctrl-D), Ctrl-C drops the input typed so far.
+// When both the input and the output are a terminal the lines are read with the line editor and its history,
+// otherwise as plain lines, so the redraws of the editor do not end up in a file or a pipe.
 func Start(in io.Reader, out io.Writer) {
+	if terminal, ok := editorTerminal(in, out); ok {
+		run(newLineEditor(terminal, terminal, out, loadHistory(historyPath())), out)
+		return
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	run(newPlainReader(in, out, interrupts), out)
+}
+
+// editorTerminal returns the input when the line editor can run on it: the input and the output are terminals.
+func editorTerminal(in io.Reader, out io.Writer) (*os.File, bool) {
+	inFile, ok := in.(*os.File)
+	if !ok || !isTerminalFd(inFile.Fd()) {
+		return nil, false
+	}
+	outFile, ok := out.(*os.File)
+	if !ok || !isTerminalFd(outFile.Fd()) {
+		return nil, false
+	}
+	return inFile, true
+}
+
+// session is the state kept from one input to the next
+type session struct {
+	out     io.Writer
+	env     *object.Environment
+	tracing bool // see :trace
+	color   bool // highlight the input and the output, see highlight.Enabled
+}
+
+func run(lines lineReader, out io.Writer) {
+	s := &session{out: out, env: object.NewEnvironment(), color: highlight.Enabled(out)}
+	if editor, ok := lines.(*lineEditor); ok {
+		editor.complete = s.completions
+		editor.color = s.color
+	}
+	input := ""
+
 	for {
+		prompt := PROMPT
+		if input != "" {
+			prompt = CONTINUATION_PROMPT
+		}
 
-		fmt.Print(">>> ")
-		reader := bufio.NewReader(in)
-		userInput, err := reader.ReadString('\n')
+		line, err := lines.readLine(prompt)
+		if err == errInterrupted {
+			input = ""
+			continue
+		}
 		if err != nil {
-			log.Fatal(err)
+			if strings.TrimSpace(input) != "" { // left incomplete at the end of the input
+				_, _ = io.WriteString(out, "\n")
+				s.evaluate("", input)
+			}
+			if err != io.EOF {
+				fmt.Fprintf(out, "\nread error: %v", err)
+			}
+			_, _ = io.WriteString(out, "\n")
+			return
 		}
 
-		l := lexer.Init(userInput)
-		p := parser.Init(l)
-		program := p.ParseProgram()
+		if input == "" && isCommand(line) {
+			s.runCommand(line)
+			continue
+		}
 
-		if len(p.Errors()) != 0 {
-			_, _ = io.WriteString(out, "Parser Errors:\n")
-			for _, msg := range p.Errors() {
-				_, _ = io.WriteString(out, "\t - "+msg+"\n")
-			}
+		input += line
+		if incomplete(input) {
 			continue
 		}
-		_, _ = io.WriteString(out, program.String())
-		_, _ = io.WriteString(out, "\n")
+		s.evaluate("", input)
+		input = ""
+	}
+}
+
+// plainReader reads the lines as they come, for pipes and tests. Reading happens in the background
+// so an interrupt does not wait for the next line.
+type plainReader struct {
+	out        io.Writer
+	lines      <-chan string
+	err        error // why the lines ended, io.EOF when the input simply ended
+	readErrors <-chan error
+	interrupts <-chan os.Signal
+}
+
+func newPlainReader(in io.Reader, out io.Writer, interrupts <-chan os.Signal) *plainReader {
+	lines := make(chan string)
+	readErrors := make(chan error, 1)
+
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				lines <- line
+			}
+			if err != nil {
+				readErrors <- err
+				return
+			}
+		}
+	}()
+	return &plainReader{out: out, lines: lines, readErrors: readErrors, interrupts: interrupts}
+}
+
+func (r *plainReader) readLine(prompt string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	_, _ = io.WriteString(r.out, prompt)
+
+	select {
+	case <-r.interrupts:
+		_, _ = io.WriteString(r.out, "\n")
+		return "", errInterrupted
+	case line, ok := <-r.lines:
+		if !ok {
+			r.err = <-r.readErrors
+			return "", r.err
+		}
+		return line, nil
+	}
+}
+
+func (s *session) evaluate(filename string, input string) {
+	l := lexer.InitFile(filename, input)
+	p := parser.Init(l)
+	if s.tracing {
+		p.SetTrace(s.out)
+	}
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		s.renderErrors(input, p.Errors())
+		return
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if evaluated != nil {
+		_, _ = io.WriteString(s.out, s.paintValue(evaluated))
+		_, _ = io.WriteString(s.out, "\n")
+	}
+}
+
+func (s *session) renderErrors(source string, diagnostics []diagnostic.Diagnostic) {
+	if !s.color {
+		diagnostic.Render(s.out, source, diagnostics)
+		return
+	}
+	var rendered strings.Builder
+	diagnostic.Render(&rendered, source, diagnostics)
+	_, _ = io.WriteString(s.out, highlight.Paint(highlight.ERROR, rendered.String()))
+}
+
+// paintValue colors the value by its type. The elements of arrays and hashes are colored by their own type,
+// their text is not source code (strings are not quoted), only functions are highlighted as source code.
+func (s *session) paintValue(value object.Object) string {
+	if !s.color {
+		return value.Inspect()
+	}
+	return paintObject(value)
+}
+
+func paintObject(value object.Object) string {
+	text := value.Inspect()
+
+	switch value := value.(type) {
+	case *object.Integer, *object.BigInteger, *object.Float:
+		return highlight.Paint(highlight.NUMBER, text)
+	case *object.Boolean:
+		return highlight.Paint(highlight.BOOLEAN, text)
+	case *object.String:
+		return highlight.Paint(highlight.STRING, text)
+	case *object.Null:
+		return highlight.Paint(highlight.KEYWORD, text)
+	case *object.Error:
+		return highlight.Paint(highlight.ERROR, text)
+	case *object.Function:
+		return highlight.ANSI(text)
+	case *object.Array:
+		elements := make([]string, len(value.Elements))
+		for i, element := range value.Elements {
+			elements[i] = paintObject(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *object.Hash:
+		pairs := make([]string, len(value.Keys))
+		for i, key := range value.Keys {
+			pair := value.Pairs[key]
+			pairs[i] = paintObject(pair.Key) + ": " + paintObject(pair.Value)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
 	}
+	return text
 }
@@ -0,0 +1,160 @@
+package repl
+
+import (
+	"arcane/object"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ">>> \n"},
+		{"1 + 2\n", ">>> 3\n>>> \n"},
+		{"let x = 5;\nx * 2\n", ">>> >>> 10\n>>> \n"},
+		{"let add = fn(a, b) {\n  a + b\n};\nadd(1,\n2)\n", ">>> ... ... >>> ... 3\n>>> \n"},
+		{"1 + 2", ">>> 3\n>>> \n"}, // no newline before the end of the input
+		{"1 +\n)\n", ">>> ... " +
+			"error[E0002]: no prefix parse function for ) found\n --> 2:1\n  |\n2 | )\n  | ^\n  = note: ) cannot start an expression\n>>> \n"},
+		{"let x = [1\n", ">>> ... \n" + // left incomplete at the end of the input
+			"error[E0001]: Expected next token to be ]. got EOF\n --> 2:1\n  |\n2 | \n  | ^\n\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		run(newPlainReader(strings.NewReader(tt.input), &out, nil), &out)
+
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected\n%q\ngot\n%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestStartReadError(t *testing.T) {
+	in := io.MultiReader(strings.NewReader("1\n"), iotest.ErrReader(errors.New("gone")))
+	var out bytes.Buffer
+	run(newPlainReader(in, &out, nil), &out)
+
+	expected := ">>> 1\n>>> \nread error: gone\n"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected %q, got %q", expected, out.String())
+	}
+}
+
+// the line editor is only used when the output is a terminal too, ex. not with arcane > out.txt
+func TestEditorTerminal(t *testing.T) {
+	terminal, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil || !isTerminalFd(terminal.Fd()) {
+		t.Skip("no pseudo terminal available")
+	}
+	defer terminal.Close()
+	file, err := os.Create(t.TempDir() + "/out.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		in       io.Reader
+		out      io.Writer
+		expected bool
+	}{
+		{terminal, terminal, true},
+		{terminal, file, false},
+		{terminal, &bytes.Buffer{}, false},
+		{file, terminal, false},
+		{strings.NewReader(""), terminal, false},
+	}
+
+	for i, tt := range tests {
+		if _, ok := editorTerminal(tt.in, tt.out); ok != tt.expected {
+			t.Errorf("tests[%d]: wrong choice of the line editor. expected %t, got %t", i, tt.expected, ok)
+		}
+	}
+}
+
+// Ctrl-C while a function is half typed drops it, the next input starts afresh
+func TestStartInterrupt(t *testing.T) {
+	in, typing := io.Pipe()
+	out := &syncBuffer{}
+	interrupts := make(chan os.Signal)
+	done := make(chan struct{})
+
+	go func() {
+		run(newPlainReader(in, out, interrupts), out)
+		close(done)
+	}()
+
+	_, _ = io.WriteString(typing, "let f = fn(x) {\n")
+	out.waitFor(t, ">>> ... ")
+	interrupts <- os.Interrupt
+	out.waitFor(t, ">>> ... \n>>> ")
+	_, _ = io.WriteString(typing, "1 + 1\n")
+	_ = typing.Close()
+	<-done
+
+	expected := ">>> ... \n>>> 2\n>>> \n"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected %q, got %q", expected, out.String())
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) waitFor(t *testing.T, output string) {
+	t.Helper()
+	for start := time.Now(); b.String() != output; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("timed out waiting for %q, got %q", output, b.String())
+		}
+	}
+}
+
+func TestEvaluateColors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2", "\x1b[33m3\x1b[0m\n"},
+		{"\"let\"", "\x1b[32mlet\x1b[0m\n"},
+		{"[1, true]", "[\x1b[33m1\x1b[0m, \x1b[34mtrue\x1b[0m]\n"},
+		{`["let x", "// hi", 1]`, "[\x1b[32mlet x\x1b[0m, \x1b[32m// hi\x1b[0m, \x1b[33m1\x1b[0m]\n"},
+		{`{"if": [if (false) { 1 }], 2: "a"}`, "{\x1b[32mif\x1b[0m: [\x1b[1;35mnull\x1b[0m], \x1b[33m2\x1b[0m: \x1b[32ma\x1b[0m}\n"},
+		{"1 + true", "\x1b[1;31mERROR: type mismatch: INTEGER + BOOLEAN\x1b[0m\n"},
+		{"let = 1", "\x1b[1;31merror[E0001]: "},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		s := &session{out: &out, env: object.NewEnvironment(), color: true}
+		s.evaluate("", tt.input)
+
+		if !strings.HasPrefix(out.String(), tt.expected) {
+			t.Errorf("wrong output for %q. expected %q, got %q", tt.input, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package repl
+
+import "errors"
+
+// there is no raw mode here, the REPL reads plain lines
+
+type terminalState struct{}
+
+func isTerminalFd(fd uintptr) bool { return false }
+
+func makeRaw(fd uintptr) (*terminalState, error) {
+	return nil, errors.New("raw terminal mode is not supported on this system")
+}
+
+func restoreTerminal(fd uintptr, state *terminalState) error { return nil }
@@ -0,0 +1,58 @@
+//go:build linux || darwin
+
+package repl
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminalState is the terminal mode to restore after reading a line in raw mode
+type terminalState struct {
+	termios syscall.Termios
+}
+
+func getTermios(fd uintptr) (syscall.Termios, error) {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios)))
+	if errno != 0 {
+		return termios, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminalFd(fd uintptr) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// makeRaw turns off the line buffering, the echo and the signal keys, so the editor sees every key as it is typed.
+// Output processing stays on, \n still starts a new line.
+func makeRaw(fd uintptr) (*terminalState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: termios}
+
+	termios.Iflag &^= syscall.ICRNL | syscall.INLCR | syscall.IXON | syscall.ISTRIP | syscall.BRKINT
+	termios.Lflag &^= syscall.ICANON | syscall.ECHO | syscall.ISIG | syscall.IEXTEN
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, &termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func restoreTerminal(fd uintptr, state *terminalState) error {
+	return setTermios(fd, &state.termios)
+}
@@ -1,5 +1,10 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 const (
 	ILLEGAL TokenType = iota // identify unknown tokens
 	EOF                      // tell the parse the stop
@@ -7,6 +12,7 @@ const (
 	//Identifiers
 	IDENT
 	INT
+	FLOAT
 	STRING
 	BOOL
 
@@ -17,22 +23,30 @@ const (
 	MULTIPLY
 	DIVIDE
 	MODULES
+	POWER
 
 	NOT
 	EQUAL
 	NOT_EQUAL
 	GT
 	LT
+	GT_EQUAL
+	LT_EQUAL
+	AND
+	OR
 
 	//Delimiters
 	COMMA
 	SEMICOLON
+	COLON
 	DOT
 
 	LEFT_PARENTHESIS
 	RIGHT_PARENTHESIS
 	LEFT_CURLY_BRACKETS
 	RIGHT_CURLY_BRACKETS
+	LEFT_SQUARE_BRACKETS
+	RIGHT_SQUARE_BRACKETS
 
 	//keywords
 	FUNCTION
@@ -51,6 +65,7 @@ var Tokens = map[TokenType]string{
 	//Identifiers
 	IDENT:  "IDENT",
 	INT:    "INT",
+	FLOAT:  "FLOAT",
 	STRING: "STRING",
 	BOOL:   "BOOL",
 
@@ -61,23 +76,31 @@ var Tokens = map[TokenType]string{
 	MULTIPLY: "*",
 	DIVIDE:   "/",
 	MODULES:  "%",
+	POWER:    "**",
 
 	NOT:       "!",
 	EQUAL:     "==",
 	NOT_EQUAL: "!=",
 
-	GT: ">",
-	LT: "<",
+	GT:       ">",
+	LT:       "<",
+	GT_EQUAL: ">=",
+	LT_EQUAL: "<=",
+	AND:      "&&",
+	OR:       "||",
 
 	//Delimiters
 	COMMA:     ",",
 	SEMICOLON: ";",
+	COLON:     ":",
 	DOT:       ".",
 
-	LEFT_PARENTHESIS:     "(",
-	RIGHT_PARENTHESIS:    ")",
-	LEFT_CURLY_BRACKETS:  "{",
-	RIGHT_CURLY_BRACKETS: "}",
+	LEFT_PARENTHESIS:      "(",
+	RIGHT_PARENTHESIS:     ")",
+	LEFT_CURLY_BRACKETS:   "{",
+	RIGHT_CURLY_BRACKETS:  "}",
+	LEFT_SQUARE_BRACKETS:  "[",
+	RIGHT_SQUARE_BRACKETS: "]",
 
 	//keywords
 	FUNCTION: "FUNCTION",
@@ -99,6 +122,16 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 }
 
+// Keywords returns the spelling of every keyword, sorted.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func LookupIdentifier(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
 		return tok
@@ -109,8 +142,49 @@ func LookupIdentifier(ident string) TokenType {
 type TokenType int
 type TokenLiteral string
 type Token struct {
-	Type    TokenType
-	Literal TokenLiteral
+	Type    TokenType    `json:"type"`
+	Literal TokenLiteral `json:"literal"`
+	Span    Span         `json:"span"` // where the token is in the source
+
+	// only set when the lexer keeps comments
+	LeadingComments  []Comment `json:"leadingComments,omitempty"`  // comments between the previous token's line and this token
+	TrailingComments []Comment `json:"trailingComments,omitempty"` // comments after this token on the same line
+}
+
+// Comment is a // line or /* */ block comment, Text includes the comment markers.
+type Comment struct {
+	Text string `json:"text"`
+	Span Span   `json:"span"`
 }
 
-//TODO: Attach filename and line number, and column number to token(using io.reader), to better track for lexing and parsing errors.
+func (t TokenType) String() string { return Tokens[t] }
+
+func (t TokenType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+// IsOperator reports whether the token is an operator or a delimiter, spelled as its entry in Tokens.
+func (t TokenType) IsOperator() bool { return ASSIGN <= t && t <= RIGHT_SQUARE_BRACKETS }
+
+// Position is a location in the source code.
+type Position struct {
+	Filename string `json:"filename,omitempty"` // empty when the source does not come from a file (ex. the REPL)
+	Offset   int    `json:"offset"`             // byte offset, starting at 0
+	Line     int    `json:"line"`               // starting at 1
+	Column   int    `json:"column"`             // starting at 1
+}
+
+func (p Position) String() string {
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the range of source code from Start up to, but not including, End.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+func (s Span) String() string {
+	return s.Start.String()
+}